@@ -2,8 +2,15 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,14 +23,55 @@ const (
 type Config struct {
 	Server      ServerConfig
 	Service     ServiceConfig
+	Metrics     MetricsConfig
+	Access      AccessConfig
+	Log         LogConfig
+	Probe       ProbeConfig
+	API         APIConfig
+	Tasks       TasksConfig
+	ExitCodes   ExitCodesConfig
+	Tracing     TracingConfig
 	Environment string
 }
 
 type ServerConfig struct {
-	Port            string
-	ReadTimeout     time.Duration
-	WriteTimeout    time.Duration
+	// BindAddress is the IP to listen on, empty listens on all interfaces
+	BindAddress  string
+	Port         int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// ReadHeaderTimeout bounds reading request headers, guarding against
+	// Slowloris-style clients
+	ReadHeaderTimeout time.Duration
+	// IdleTimeout bounds how long keep-alive connections wait for the next
+	// request
+	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	RequestTimeout  time.Duration
+	// RouteTimeouts overrides RequestTimeout for specific route patterns
+	RouteTimeouts map[string]time.Duration
+	// MaxRequestBytes caps the size of request bodies
+	MaxRequestBytes int64
+	// TLSCertFile and TLSKeyFile enable HTTPS (and HTTP/2) when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// Addr returns the listen address, bracketing IPv6 bind addresses.
+func (c ServerConfig) Addr() string {
+	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.Port))
+}
+
+// fitsWriteTimeout reports whether a request deadline expires before the
+// server's write timeout, so its JSON 503 can still reach the client. Zero
+// disables either timeout.
+func (c ServerConfig) fitsWriteTimeout(timeout time.Duration) bool {
+	return timeout == 0 || c.WriteTimeout == 0 || timeout < c.WriteTimeout
+}
+
+// TLSEnabled reports whether the server should terminate TLS itself.
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
 type ServiceConfig struct {
@@ -31,20 +79,213 @@ type ServiceConfig struct {
 	Version string
 }
 
+type MetricsConfig struct {
+	PushgatewayURL      string
+	PushgatewayJob      string
+	PushgatewayInterval time.Duration
+	// DurationBuckets overrides the request duration histogram buckets
+	DurationBuckets []float64
+	// Token protects /metrics with bearer authentication when set
+	Token string
+}
+
+type AccessConfig struct {
+	// TrustedProxies are the CIDRs whose forwarding headers are believed
+	TrustedProxies []string
+	AllowCIDRs     []string
+	DenyCIDRs      []string
+	// CORSAllowedOrigins enables CORS for these origins, "*" allows any
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	// RateLimitRPS enables per-client rate limiting when positive
+	RateLimitRPS   float64
+	RateLimitBurst int
+}
+
+type LogConfig struct {
+	// Format is either LogFormatText or LogFormatJSON (ECS)
+	Format string
+	// Level is one of debug, info, warn or error
+	Level string
+	// SampleRate is the fraction of successful requests that get an access
+	// log, 1 logs every request
+	SampleRate float64
+	// SlowThreshold logs slower requests regardless of sampling, zero
+	// disables the exemption
+	SlowThreshold time.Duration
+}
+
+// SlogLevel returns the configured level for use in slog handler options.
+func (c LogConfig) SlogLevel() slog.Level {
+	switch c.Level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+type ProbeConfig struct {
+	// ReadyMaxConcurrency caps concurrent /ready checks, zero means unlimited
+	ReadyMaxConcurrency int
+	// MinimalBody makes /health, /livez and /ready respond without a body
+	MinimalBody bool
+	// MinimalStatus replaces 200 on success when MinimalBody is set
+	MinimalStatus int
+	// CheckTimeout bounds each readiness check
+	CheckTimeout time.Duration
+	// SuccessThreshold is how many consecutive passing evaluations flip
+	// readiness back to ready, and FailureThreshold how many consecutive
+	// failing ones flip it to not ready
+	SuccessThreshold int
+	FailureThreshold int
+	// WatchdogInterval is how often the liveness watchdog ticks
+	WatchdogInterval time.Duration
+	// StallThreshold is how stale the watchdog may get before /livez fails
+	StallThreshold time.Duration
+}
+
+type APIConfig struct {
+	// Deprecations maps route patterns to the upgrade notice sent with them
+	Deprecations map[string]string
+	// JSONCharset is appended to the JSON content type, empty omits it
+	JSONCharset string
+}
+
+// JSONContentType returns the content type of JSON responses, with the
+// configured charset.
+func (c APIConfig) JSONContentType() string {
+	if c.JSONCharset == "" {
+		return "application/json"
+	}
+	return "application/json; charset=" + c.JSONCharset
+}
+
+type TasksConfig struct {
+	// Jitter varies periodic task intervals by up to ±Jitter (0.1 is ±10%)
+	Jitter float64
+}
+
+// ExitCodesConfig maps shutdown outcomes to process exit codes.
+type ExitCodesConfig struct {
+	// Clean is used when in-flight requests drained in time
+	Clean int
+	// DrainTimeout is used when the shutdown timeout elapsed before draining
+	DrainTimeout int
+	// Signal is used when a second signal aborts the drain
+	Signal int
+}
+
+type TracingConfig struct {
+	// OTLPEndpoint is the OTLP/HTTP collector URL, empty disables export. It
+	// only switches export on; the exporter resolves the URL from the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	OTLPEndpoint string
+}
+
 func Load() (*Config, error) {
+	// Variables from the optional config file fill gaps in the environment
+	if err := loadEnvFile(getEnv("CONFIG_FILE", ".env")); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	port, err := strconv.Atoi(getEnv("PORT", "8080"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: invalid port number: %s", os.Getenv("PORT"))
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:            getEnv("PORT", "8080"),
-			ReadTimeout:     getEnvDuration("READ_TIMEOUT", 15*time.Second),
-			WriteTimeout:    getEnvDuration("WRITE_TIMEOUT", 15*time.Second),
-			ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			// Brackets are accepted around IPv6 addresses, e.g. [::1]
+			BindAddress:       strings.TrimSuffix(strings.TrimPrefix(getEnv("BIND_ADDRESS", ""), "["), "]"),
+			Port:              port,
+			ReadTimeout:       getEnvDuration("READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:      getEnvDuration("WRITE_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getEnvDuration("READ_HEADER_TIMEOUT", 5*time.Second),
+			IdleTimeout:       getEnvDuration("IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			RequestTimeout:    getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+			MaxRequestBytes:   int64(getEnvInt("MAX_REQUEST_BYTES", 1<<20)),
+			TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
 		},
 		Service: ServiceConfig{
-			Name:    ServiceName,
-			Version: ServiceVersion,
+			Name:    getEnv("SERVICE_NAME", ServiceName),
+			Version: getEnv("SERVICE_VERSION", ServiceVersion),
+		},
+		Metrics: MetricsConfig{
+			PushgatewayURL:      getEnv("PUSHGATEWAY_URL", ""),
+			PushgatewayJob:      getEnv("PUSHGATEWAY_JOB", getEnv("SERVICE_NAME", ServiceName)),
+			PushgatewayInterval: getEnvDuration("PUSHGATEWAY_INTERVAL", 0),
+			Token:               getEnv("METRICS_TOKEN", ""),
+		},
+		Access: AccessConfig{
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+			AllowCIDRs:     getEnvList("IP_ALLOWLIST"),
+			DenyCIDRs:      getEnvList("IP_DENYLIST"),
+
+			CORSAllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS"),
+			CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+
+			RateLimitRPS:   getEnvFloat("RATE_LIMIT_RPS", 0),
+			RateLimitBurst: getEnvInt("RATE_LIMIT_BURST", 0),
+		},
+		Probe: ProbeConfig{
+			ReadyMaxConcurrency: getEnvInt("READY_MAX_CONCURRENCY", 0),
+			MinimalBody:         getEnvBool("MINIMAL_PROBE_BODY", false),
+			MinimalStatus:       getEnvInt("MINIMAL_PROBE_STATUS", http.StatusNoContent),
+			CheckTimeout:        getEnvDuration("READINESS_CHECK_TIMEOUT", 2*time.Second),
+			SuccessThreshold:    getEnvInt("READINESS_SUCCESS_THRESHOLD", 1),
+			FailureThreshold:    getEnvInt("READINESS_FAILURE_THRESHOLD", 1),
+			WatchdogInterval:    getEnvDuration("LIVENESS_WATCHDOG_INTERVAL", time.Second),
+			StallThreshold:      getEnvDuration("LIVENESS_STALL_THRESHOLD", 10*time.Second),
+		},
+		API: APIConfig{
+			Deprecations: getEnvMap("API_DEPRECATIONS", ";"),
+			JSONCharset:  getEnvDefault("JSON_CHARSET", "utf-8"),
+		},
+		Tasks: TasksConfig{
+			Jitter: getEnvFloat("TASK_JITTER", 0.1),
+		},
+		ExitCodes: ExitCodesConfig{
+			Clean:        getEnvInt("EXIT_CODE_CLEAN", 0),
+			DrainTimeout: getEnvInt("EXIT_CODE_DRAIN_TIMEOUT", 1),
+			Signal:       getEnvInt("EXIT_CODE_SIGNAL", 1),
+		},
+		Tracing: TracingConfig{
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")),
 		},
 		Environment: getEnv("ENVIRONMENT", "development"),
 	}
+	// Without an explicit burst, allow one second's worth of requests
+	if cfg.Access.RateLimitBurst == 0 {
+		cfg.Access.RateLimitBurst = max(1, int(math.Ceil(cfg.Access.RateLimitRPS)))
+	}
+	cfg.Log.Format = strings.ToLower(getEnv("LOG_FORMAT", defaultLogFormat(cfg.Environment)))
+	cfg.Log.Level = strings.ToLower(getEnv("LOG_LEVEL", "info"))
+	cfg.Log.SampleRate = getEnvFloat("LOG_SAMPLE_RATE", 1)
+	cfg.Log.SlowThreshold = getEnvDuration("LOG_SLOW_THRESHOLD", time.Second)
+
+	routeTimeouts, err := getEnvDurationMap("ROUTE_TIMEOUTS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.Server.RouteTimeouts = routeTimeouts
+
+	durationBuckets, err := getEnvFloatList("HTTP_DURATION_BUCKETS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.Metrics.DurationBuckets = durationBuckets
 
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -54,12 +295,133 @@ func Load() (*Config, error) {
 }
 
 func (c *Config) Validate() error {
-	if c.Server.Port == "" {
-		return fmt.Errorf("server port cannot be empty")
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("port number out of range: %d", c.Server.Port)
+	}
+
+	if c.Server.BindAddress != "" {
+		if _, err := netip.ParseAddr(c.Server.BindAddress); err != nil {
+			return fmt.Errorf("invalid bind address: %s", c.Server.BindAddress)
+		}
+	}
+
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		return fmt.Errorf("TLS cert file and key file must be set together")
+	}
+
+	if c.Server.ReadHeaderTimeout < 0 {
+		return fmt.Errorf("read header timeout cannot be negative: %s", c.Server.ReadHeaderTimeout)
+	}
+
+	if c.Server.IdleTimeout < 0 {
+		return fmt.Errorf("idle timeout cannot be negative: %s", c.Server.IdleTimeout)
+	}
+
+	if c.Server.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout cannot be negative: %s", c.Server.RequestTimeout)
 	}
 
-	if _, err := strconv.Atoi(c.Server.Port); err != nil {
-		return fmt.Errorf("invalid port number: %s", c.Server.Port)
+	if !c.Server.fitsWriteTimeout(c.Server.RequestTimeout) {
+		return fmt.Errorf("request timeout %s must be below the write timeout %s", c.Server.RequestTimeout, c.Server.WriteTimeout)
+	}
+
+	for route, timeout := range c.Server.RouteTimeouts {
+		if !strings.HasPrefix(route, "/") || timeout < 0 {
+			return fmt.Errorf("invalid timeout for route: %s", route)
+		}
+		if !c.Server.fitsWriteTimeout(timeout) {
+			return fmt.Errorf("timeout %s for route %s must be below the write timeout %s", timeout, route, c.Server.WriteTimeout)
+		}
+	}
+
+	if c.Server.MaxRequestBytes <= 0 {
+		return fmt.Errorf("max request bytes must be positive: %d", c.Server.MaxRequestBytes)
+	}
+
+	for i, bucket := range c.Metrics.DurationBuckets {
+		if bucket <= 0 || (i > 0 && bucket <= c.Metrics.DurationBuckets[i-1]) {
+			return fmt.Errorf("duration buckets must be positive and increasing: %v", c.Metrics.DurationBuckets)
+		}
+	}
+
+	if c.Metrics.PushgatewayURL != "" && c.Metrics.PushgatewayJob == "" {
+		return fmt.Errorf("pushgateway job cannot be empty")
+	}
+
+	if c.Metrics.PushgatewayInterval < 0 {
+		return fmt.Errorf("pushgateway interval cannot be negative: %s", c.Metrics.PushgatewayInterval)
+	}
+
+	for _, cidr := range slices.Concat(c.Access.TrustedProxies, c.Access.AllowCIDRs, c.Access.DenyCIDRs) {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR: %s", cidr)
+		}
+	}
+
+	if c.Access.CORSAllowCredentials && slices.Contains(c.Access.CORSAllowedOrigins, "*") {
+		return fmt.Errorf("CORS wildcard origin cannot be combined with credentials")
+	}
+
+	if c.Access.RateLimitRPS < 0 || c.Access.RateLimitBurst < 1 {
+		return fmt.Errorf("rate limit must be non-negative with a burst of at least 1")
+	}
+
+	if c.Log.Format != LogFormatText && c.Log.Format != LogFormatJSON {
+		return fmt.Errorf("invalid log format: %s", c.Log.Format)
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log level: %s", c.Log.Level)
+	}
+
+	if c.Log.SampleRate < 0 || c.Log.SampleRate > 1 {
+		return fmt.Errorf("log sample rate must be in [0, 1]: %g", c.Log.SampleRate)
+	}
+
+	if c.Log.SlowThreshold < 0 {
+		return fmt.Errorf("log slow threshold cannot be negative: %s", c.Log.SlowThreshold)
+	}
+
+	if c.Probe.ReadyMaxConcurrency < 0 {
+		return fmt.Errorf("ready max concurrency cannot be negative: %d", c.Probe.ReadyMaxConcurrency)
+	}
+
+	if c.Probe.MinimalStatus != http.StatusOK && c.Probe.MinimalStatus != http.StatusNoContent {
+		return fmt.Errorf("minimal probe status must be 200 or 204: %d", c.Probe.MinimalStatus)
+	}
+
+	if c.Probe.CheckTimeout <= 0 {
+		return fmt.Errorf("readiness check timeout must be positive: %s", c.Probe.CheckTimeout)
+	}
+
+	if c.Probe.SuccessThreshold < 1 || c.Probe.FailureThreshold < 1 {
+		return fmt.Errorf("readiness thresholds must be at least 1: success %d, failure %d", c.Probe.SuccessThreshold, c.Probe.FailureThreshold)
+	}
+
+	if c.Probe.WatchdogInterval <= 0 {
+		return fmt.Errorf("liveness watchdog interval must be positive: %s", c.Probe.WatchdogInterval)
+	}
+
+	if c.Probe.StallThreshold <= c.Probe.WatchdogInterval {
+		return fmt.Errorf("liveness stall threshold must exceed the watchdog interval: %s", c.Probe.StallThreshold)
+	}
+
+	for route, notice := range c.API.Deprecations {
+		if !strings.HasPrefix(route, "/") || notice == "" {
+			return fmt.Errorf("invalid deprecation notice for route: %s", route)
+		}
+	}
+
+	if c.Tasks.Jitter < 0 || c.Tasks.Jitter >= 1 {
+		return fmt.Errorf("task jitter must be in [0, 1): %g", c.Tasks.Jitter)
+	}
+
+	for _, code := range []int{c.ExitCodes.Clean, c.ExitCodes.DrainTimeout, c.ExitCodes.Signal} {
+		if code < 0 || code > 125 {
+			return fmt.Errorf("exit code must be in [0, 125]: %d", code)
+		}
 	}
 
 	if c.Service.Name == "" {
@@ -73,6 +435,24 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// defaultLogFormat favours readable logs locally and structured ECS logs in
+// every other environment.
+func defaultLogFormat(environment string) string {
+	if environment == "development" {
+		return LogFormatText
+	}
+	return LogFormatJSON
+}
+
+// getEnvDefault is like getEnv but lets an explicitly empty variable override
+// the default.
+func getEnvDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -80,6 +460,85 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvMap parses "key=value" pairs separated by sep.
+func getEnvMap(key, sep string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), sep) {
+		k, v, _ := strings.Cut(pair, "=")
+		if k = strings.TrimSpace(k); k != "" {
+			values[k] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+// getEnvDurationMap parses comma-separated "key=duration" pairs. Unlike
+// getEnvDuration it rejects malformed values, since silently dropping one
+// entry would be hard to notice.
+func getEnvDurationMap(key string) (map[string]time.Duration, error) {
+	durations := make(map[string]time.Duration)
+	for k, v := range getEnvMap(key, ",") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for %s in %s: %s", k, key, v)
+		}
+		durations[k] = d
+	}
+	return durations, nil
+}
+
+// getEnvFloatList parses a comma-separated list of numbers, rejecting
+// malformed entries.
+func getEnvFloatList(key string) ([]float64, error) {
+	var values []float64
+	for _, value := range getEnvList(key) {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number in %s: %s", key, value)
+		}
+		values = append(values, f)
+	}
+	return values, nil
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefaultsAreValid(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.RequestTimeout >= cfg.Server.WriteTimeout {
+		t.Errorf("default request timeout %s is not below the write timeout %s", cfg.Server.RequestTimeout, cfg.Server.WriteTimeout)
+	}
+}
+
+func TestRequestTimeoutMustBeBelowWriteTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "request timeout above write timeout",
+			env:     map[string]string{"REQUEST_TIMEOUT": "60s", "WRITE_TIMEOUT": "15s"},
+			wantErr: "request timeout 1m0s must be below the write timeout 15s",
+		},
+		{
+			name:    "request timeout equal to write timeout",
+			env:     map[string]string{"REQUEST_TIMEOUT": "15s", "WRITE_TIMEOUT": "15s"},
+			wantErr: "must be below the write timeout",
+		},
+		{
+			name:    "route timeout above write timeout",
+			env:     map[string]string{"ROUTE_TIMEOUTS": "/ping=30s", "WRITE_TIMEOUT": "15s"},
+			wantErr: "timeout 30s for route /ping must be below the write timeout 15s",
+		},
+		{
+			name: "disabled request timeout",
+			env:  map[string]string{"REQUEST_TIMEOUT": "0", "ROUTE_TIMEOUTS": "/ping=0"},
+		},
+		{
+			name: "disabled write timeout",
+			env:  map[string]string{"REQUEST_TIMEOUT": "5m", "WRITE_TIMEOUT": "0"},
+		},
+		{
+			name: "below write timeout",
+			env:  map[string]string{"REQUEST_TIMEOUT": "5s", "ROUTE_TIMEOUTS": "/ping=1s", "WRITE_TIMEOUT": "15s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Load()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Load() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteTimeoutsParsing(t *testing.T) {
+	t.Setenv("ROUTE_TIMEOUTS", "/ping=1s, /ready=250ms")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]time.Duration{"/ping": time.Second, "/ready": 250 * time.Millisecond}
+	for route, timeout := range want {
+		if got := cfg.Server.RouteTimeouts[route]; got != timeout {
+			t.Errorf("RouteTimeouts[%s] = %s, want %s", route, got, timeout)
+		}
+	}
+}
+
+func TestJSONContentType(t *testing.T) {
+	for charset, want := range map[string]string{
+		"utf-8": "application/json; charset=utf-8",
+		"":      "application/json",
+	} {
+		if got := (APIConfig{JSONCharset: charset}).JSONContentType(); got != want {
+			t.Errorf("JSONContentType() with charset %q = %q, want %q", charset, got, want)
+		}
+	}
+}
+
+func TestDefaultLogFormat(t *testing.T) {
+	tests := []struct {
+		environment string
+		logFormat   string
+		want        string
+	}{
+		{environment: "development", want: LogFormatText},
+		{environment: "staging", want: LogFormatJSON},
+		{environment: "production", want: LogFormatJSON},
+		{environment: "production", logFormat: "TEXT", want: LogFormatText},
+		{environment: "development", logFormat: "json", want: LogFormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.environment+"/"+tt.logFormat, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			t.Setenv("LOG_FORMAT", tt.logFormat)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Log.Format != tt.want {
+				t.Errorf("Log.Format = %q, want %q", cfg.Log.Format, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := (ExitCodesConfig{Clean: 0, DrainTimeout: 1, Signal: 1}); cfg.ExitCodes != want {
+		t.Errorf("default ExitCodes = %+v, want %+v", cfg.ExitCodes, want)
+	}
+
+	t.Setenv("EXIT_CODE_DRAIN_TIMEOUT", "3")
+	t.Setenv("EXIT_CODE_SIGNAL", "130")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "exit code must be in [0, 125]: 130") {
+		t.Errorf("Load() error = %v, want an out of range exit code", err)
+	}
+
+	t.Setenv("EXIT_CODE_SIGNAL", "4")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ExitCodes.DrainTimeout != 3 || cfg.ExitCodes.Signal != 4 {
+		t.Errorf("ExitCodes = %+v", cfg.ExitCodes)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{level: "", want: slog.LevelInfo},
+		{level: "debug", want: slog.LevelDebug},
+		{level: "WARN", want: slog.LevelWarn},
+		{level: "error", want: slog.LevelError},
+		{level: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.level)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid log level") {
+					t.Errorf("Load() error = %v, want invalid log level", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := cfg.Log.SlogLevel(); got != tt.want {
+				t.Errorf("SlogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationBucketsParsing(t *testing.T) {
+	tests := []struct {
+		buckets string
+		want    []float64
+		wantErr bool
+	}{
+		{buckets: "", want: nil},
+		{buckets: "0.001, 0.01,0.1", want: []float64{0.001, 0.01, 0.1}},
+		{buckets: "0.1,fast", wantErr: true},
+		{buckets: "0.1,0.01", wantErr: true},
+		{buckets: "0,0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.buckets, func(t *testing.T) {
+			t.Setenv("HTTP_DURATION_BUCKETS", tt.buckets)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(cfg.Metrics.DurationBuckets, tt.want) {
+				t.Errorf("DurationBuckets = %v, want %v", cfg.Metrics.DurationBuckets, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceIdentity(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Service.Name != ServiceName || cfg.Service.Version != ServiceVersion {
+		t.Errorf("default Service = %+v", cfg.Service)
+	}
+	if cfg.Metrics.PushgatewayJob != ServiceName {
+		t.Errorf("default PushgatewayJob = %q, want %q", cfg.Metrics.PushgatewayJob, ServiceName)
+	}
+
+	t.Setenv("SERVICE_NAME", "edge-ping")
+	t.Setenv("SERVICE_VERSION", "2.1.0")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Service.Name != "edge-ping" || cfg.Service.Version != "2.1.0" {
+		t.Errorf("Service = %+v", cfg.Service)
+	}
+	// The Pushgateway job follows the service name unless set
+	if cfg.Metrics.PushgatewayJob != "edge-ping" {
+		t.Errorf("PushgatewayJob = %q, want edge-ping", cfg.Metrics.PushgatewayJob)
+	}
+}
+
+func TestNegativeTimeoutsRejected(t *testing.T) {
+	for _, key := range []string{"READ_HEADER_TIMEOUT", "IDLE_TIMEOUT", "REQUEST_TIMEOUT"} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, "-1s")
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+				t.Errorf("Load() error = %v, want a negative timeout error", err)
+			}
+		})
+	}
+}
+
+func TestBindAddress(t *testing.T) {
+	tests := []struct {
+		bindAddress string
+		want        string
+		wantErr     bool
+	}{
+		{bindAddress: "", want: ":8080"},
+		{bindAddress: "127.0.0.1", want: "127.0.0.1:8080"},
+		{bindAddress: "::1", want: "[::1]:8080"},
+		{bindAddress: "[::]", want: "[::]:8080"},
+		{bindAddress: "localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bindAddress, func(t *testing.T) {
+			t.Setenv("BIND_ADDRESS", tt.bindAddress)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Server.Addr() != tt.want {
+				t.Errorf("Addr() = %q, want %q", cfg.Server.Addr(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSFilesSetTogether(t *testing.T) {
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		enabled bool
+		wantErr bool
+	}{
+		{name: "neither"},
+		{name: "both", cert: "cert.pem", key: "key.pem", enabled: true},
+		{name: "cert only", cert: "cert.pem", wantErr: true},
+		{name: "key only", key: "key.pem", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TLS_CERT_FILE", tt.cert)
+			t.Setenv("TLS_KEY_FILE", tt.key)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Server.TLSEnabled() != tt.enabled {
+				t.Errorf("TLSEnabled() = %v, want %v", cfg.Server.TLSEnabled(), tt.enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unsetenv clears key for the test and restores it afterwards.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# comment
+export PORT=9090
+SERVICE_NAME="from-file"
+SERVICE_VERSION='2.0.0'
+
+ENVIRONMENT=staging
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"PORT", "SERVICE_NAME", "SERVICE_VERSION", "ENVIRONMENT"} {
+		unsetenv(t, key)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	// The environment takes precedence over the file
+	t.Setenv("ENVIRONMENT", "production")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Port = %d, want 9090", cfg.Server.Port)
+	}
+	if cfg.Service.Name != "from-file" || cfg.Service.Version != "2.0.0" {
+		t.Errorf("Service = %+v", cfg.Service)
+	}
+	if cfg.Environment != "production" {
+		t.Errorf("Environment = %q, want production", cfg.Environment)
+	}
+}
+
+func TestLoadEnvFileErrors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.env"))
+		if _, err := Load(); err != nil {
+			t.Errorf("Load() error = %v", err)
+		}
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte("PORT\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("CONFIG_FILE", path)
+		if _, err := Load(); err == nil {
+			t.Error("Load() succeeded with a malformed config file")
+		}
+	})
+}
+
+func TestPortParsing(t *testing.T) {
+	for port, wantErr := range map[string]bool{
+		"8080":  false,
+		"65535": false,
+		"0":     true,
+		"70000": true,
+		"http":  true,
+	} {
+		t.Run(port, func(t *testing.T) {
+			t.Setenv("PORT", port)
+			if _, err := Load(); (err != nil) != wantErr {
+				t.Errorf("Load() error = %v, want error %v", err, wantErr)
+			}
+		})
+	}
+}
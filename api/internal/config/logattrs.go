@@ -0,0 +1,90 @@
+package config
+
+import (
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// redacted replaces secret values in logged configuration.
+const redacted = "[REDACTED]"
+
+// LogAttrs returns the resolved configuration as flat log attributes, with
+// secrets masked. The service name, version and environment are left out as
+// the logger already attaches them to every record, and keys such as "port"
+// match those the ECS logger maps to standard fields.
+func (c *Config) LogAttrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("bind_address", c.Server.BindAddress),
+		slog.Int("port", c.Server.Port),
+		slog.String("read_timeout", c.Server.ReadTimeout.String()),
+		slog.String("write_timeout", c.Server.WriteTimeout.String()),
+		slog.String("read_header_timeout", c.Server.ReadHeaderTimeout.String()),
+		slog.String("idle_timeout", c.Server.IdleTimeout.String()),
+		slog.String("shutdown_timeout", c.Server.ShutdownTimeout.String()),
+		slog.String("request_timeout", c.Server.RequestTimeout.String()),
+		slog.Any("route_timeouts", durationStrings(c.Server.RouteTimeouts)),
+		slog.Int64("max_request_bytes", c.Server.MaxRequestBytes),
+		slog.Bool("tls", c.Server.TLSEnabled()),
+		slog.String("log_format", c.Log.Format),
+		slog.String("log_level", c.Log.Level),
+		slog.Float64("log_sample_rate", c.Log.SampleRate),
+		slog.String("log_slow_threshold", c.Log.SlowThreshold.String()),
+		slog.String("pushgateway_url", redactURL(c.Metrics.PushgatewayURL)),
+		slog.String("pushgateway_job", c.Metrics.PushgatewayJob),
+		slog.String("pushgateway_interval", c.Metrics.PushgatewayInterval.String()),
+		slog.Any("duration_buckets", c.Metrics.DurationBuckets),
+		slog.String("metrics_token", redactSecret(c.Metrics.Token)),
+		slog.Any("trusted_proxies", c.Access.TrustedProxies),
+		slog.Any("ip_allowlist", c.Access.AllowCIDRs),
+		slog.Any("ip_denylist", c.Access.DenyCIDRs),
+		slog.Any("cors_allowed_origins", c.Access.CORSAllowedOrigins),
+		slog.Bool("cors_allow_credentials", c.Access.CORSAllowCredentials),
+		slog.Float64("rate_limit_rps", c.Access.RateLimitRPS),
+		slog.Int("rate_limit_burst", c.Access.RateLimitBurst),
+		slog.Int("ready_max_concurrency", c.Probe.ReadyMaxConcurrency),
+		slog.Bool("probe_minimal_body", c.Probe.MinimalBody),
+		slog.Int("probe_minimal_status", c.Probe.MinimalStatus),
+		slog.String("readiness_check_timeout", c.Probe.CheckTimeout.String()),
+		slog.Int("readiness_success_threshold", c.Probe.SuccessThreshold),
+		slog.Int("readiness_failure_threshold", c.Probe.FailureThreshold),
+		slog.String("watchdog_interval", c.Probe.WatchdogInterval.String()),
+		slog.String("stall_threshold", c.Probe.StallThreshold.String()),
+		slog.Any("api_deprecations", c.API.Deprecations),
+		slog.String("json_charset", c.API.JSONCharset),
+		slog.Float64("task_jitter", c.Tasks.Jitter),
+		slog.Int("exit_code_clean", c.ExitCodes.Clean),
+		slog.Int("exit_code_drain_timeout", c.ExitCodes.DrainTimeout),
+		slog.Int("exit_code_signal", c.ExitCodes.Signal),
+		slog.String("otlp_endpoint", redactURL(c.Tracing.OTLPEndpoint)),
+	}
+}
+
+// redactSecret masks a secret while still showing whether it is set.
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL masks any password embedded in a URL.
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+func durationStrings(m map[string]time.Duration) map[string]string {
+	s := make(map[string]string, len(m))
+	for k, d := range m {
+		s[k] = d.String()
+	}
+	return s
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxAcceptLength bounds how much of an Accept header is parsed
+	maxAcceptLength = 4096
+	// maxAcceptRanges bounds how many media ranges are considered
+	maxAcceptRanges = 32
+)
+
+// mediaRange is a single entry of an Accept header, e.g. "text/*;q=0.5".
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// specificity ranks exact types above subtype and full wildcards.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+// parseAccept parses an Accept header into media ranges ordered by preference.
+// Malformed entries are skipped, and oversized headers are truncated to
+// maxAcceptLength bytes and maxAcceptRanges entries so hostile input cannot
+// cause unbounded work.
+func parseAccept(header string) []mediaRange {
+	if len(header) > maxAcceptLength {
+		header = header[:maxAcceptLength]
+		// Drop the entry that was cut in half
+		if i := strings.LastIndexByte(header, ','); i >= 0 {
+			header = header[:i]
+		}
+	}
+
+	var ranges []mediaRange
+	for _, entry := range strings.SplitN(header, ",", maxAcceptRanges+1) {
+		if len(ranges) == maxAcceptRanges {
+			break
+		}
+		if m, ok := parseMediaRange(entry); ok {
+			ranges = append(ranges, m)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+func parseMediaRange(entry string) (mediaRange, bool) {
+	params := strings.Split(entry, ";")
+
+	typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+	if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return mediaRange{}, false
+	}
+
+	m := mediaRange{typ: typ, subtype: subtype, q: 1}
+	for _, param := range params[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(key, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(value, 64)
+		if err != nil || q < 0 || q > 1 {
+			return mediaRange{}, false
+		}
+		m.q = q
+	}
+
+	return m, true
+}
+
+// negotiate picks the offered media type that best satisfies the Accept
+// header. The first offer is the fallback for an empty, unparseable or
+// unsatisfiable header.
+func negotiate(header string, offers []string) string {
+	ranges := parseAccept(header)
+	for _, m := range ranges {
+		if m.q == 0 {
+			continue
+		}
+		for _, offer := range offers {
+			if m.matches(offer) && !excluded(ranges, offer) {
+				return offer
+			}
+		}
+	}
+	return offers[0]
+}
+
+// excluded reports whether mediaType is explicitly refused with q=0, which
+// overrides any wildcard range that would otherwise match it.
+func excluded(ranges []mediaRange, mediaType string) bool {
+	for _, m := range ranges {
+		if m.q == 0 && m.specificity() == 2 && m.matches(mediaType) {
+			return true
+		}
+	}
+	return false
+}
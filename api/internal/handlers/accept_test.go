@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"empty header", "", mediaTypeJSON},
+		{"exact json", "application/json", mediaTypeJSON},
+		{"exact text", "text/plain", mediaTypeText},
+		{"full wildcard", "*/*", mediaTypeJSON},
+		{"subtype wildcard", "text/*", mediaTypeText},
+		{"case insensitive", "TEXT/PLAIN", mediaTypeText},
+		{"q-values order", "application/json;q=0.4, text/plain;q=0.9", mediaTypeText},
+		{"specificity breaks ties", "*/*;q=0.5, text/plain;q=0.5", mediaTypeText},
+		{"q=0 excludes", "application/json;q=0, */*", mediaTypeText},
+		{"q=0 only", "text/plain;q=0", mediaTypeJSON},
+		{"unsatisfiable", "image/png", mediaTypeJSON},
+		{"missing subtype", "text/, text", mediaTypeJSON},
+		{"invalid wildcard", "*/plain", mediaTypeJSON},
+		{"malformed q", "text/plain;q=high", mediaTypeJSON},
+		{"q out of range", "text/plain;q=1.5", mediaTypeJSON},
+		{"malformed entry skipped", ";;;, text/plain", mediaTypeText},
+		{"other params ignored", "text/plain;charset=utf-8;q=0.8", mediaTypeText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.header, textOfferedTypes); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLimits(t *testing.T) {
+	t.Run("too many ranges", func(t *testing.T) {
+		header := strings.Repeat("image/png, ", maxAcceptRanges) + "text/plain"
+		if got := len(parseAccept(header)); got != maxAcceptRanges {
+			t.Errorf("parsed %d ranges, want %d", got, maxAcceptRanges)
+		}
+		if got := negotiate(header, textOfferedTypes); got != mediaTypeJSON {
+			t.Errorf("negotiate() = %q, want ranges past the limit ignored", got)
+		}
+	})
+
+	t.Run("oversized header", func(t *testing.T) {
+		header := "text/plain;q=0.1, " + strings.Repeat("x", maxAcceptLength) + ", application/json"
+		ranges := parseAccept(header)
+		if len(ranges) != 1 || ranges[0].subtype != "plain" {
+			t.Errorf("parseAccept() = %+v, want only the entry before the limit", ranges)
+		}
+	})
+
+	t.Run("truncated entry dropped", func(t *testing.T) {
+		// The limit falls inside "text/plainer", leaving a valid "text/pl"
+		header := strings.Repeat(" ", maxAcceptLength-9) + ", text/plainer"
+		if ranges := parseAccept(header); len(ranges) != 0 {
+			t.Errorf("parseAccept() = %+v, want the cut entry dropped", ranges)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func countingCheck(name string, ttl time.Duration, runs *atomic.Int32) ReadinessCheck {
+	return ReadinessCheck{Name: name, TTL: ttl, Check: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}}
+}
+
+func TestReadinessCheckTTL(t *testing.T) {
+	var cheapRuns, expensiveRuns, uncachedRuns atomic.Int32
+	h := newTestHandler(t, []ReadinessCheck{
+		countingCheck("cheap", time.Second, &cheapRuns),
+		countingCheck("expensive", 10*time.Second, &expensiveRuns),
+		countingCheck("uncached", 0, &uncachedRuns),
+	})
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	h.now = clock.Now
+
+	steps := []struct {
+		advance   time.Duration
+		cheap     int32
+		expensive int32
+		uncached  int32
+	}{
+		{0, 1, 1, 1},
+		{500 * time.Millisecond, 1, 1, 2},
+		{600 * time.Millisecond, 2, 1, 3},
+		{5 * time.Second, 3, 1, 4},
+		{5 * time.Second, 4, 2, 5},
+		{100 * time.Millisecond, 4, 2, 6},
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		getReady(t, h)
+
+		if got := cheapRuns.Load(); got != step.cheap {
+			t.Errorf("step %d: cheap ran %d times, want %d", i, got, step.cheap)
+		}
+		if got := expensiveRuns.Load(); got != step.expensive {
+			t.Errorf("step %d: expensive ran %d times, want %d", i, got, step.expensive)
+		}
+		if got := uncachedRuns.Load(); got != step.uncached {
+			t.Errorf("step %d: uncached ran %d times, want %d", i, got, step.uncached)
+		}
+	}
+}
+
+func TestReadinessCheckCancelledProbeNotCached(t *testing.T) {
+	var runs atomic.Int32
+	var block atomic.Bool
+	block.Store(true)
+	h := newTestHandler(t, []ReadinessCheck{{Name: "expensive", TTL: time.Minute, Check: func(ctx context.Context) error {
+		runs.Add(1)
+		if block.Load() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}})
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	h.now = clock.Now
+
+	// The client goes away while the check is running
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil).WithContext(ctx)
+	go func() {
+		for runs.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	h.Ready(httptest.NewRecorder(), req)
+
+	block.Store(false)
+	if code, body := getReady(t, h); code != http.StatusOK {
+		t.Errorf("got %d %+v, want the check run again", code, body)
+	}
+	if got := runs.Load(); got != 2 {
+		t.Errorf("check ran %d times, want 2", got)
+	}
+}
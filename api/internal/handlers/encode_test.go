@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func TestWriteJSONEncodingFailure(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	rec := httptest.NewRecorder()
+	h.writeJSON(rec, http.StatusOK, map[string]any{"unencodable": make(chan int)})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct == h.contentType {
+		t.Errorf("Content-Type = %q, want the plain-text error", ct)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "{") {
+		t.Errorf("body = %q, want no partial JSON", body)
+	}
+}
+
+func TestWriteJSONPooledBuffersConcurrently(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 100)
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Vary the size so a reused buffer would leave stale bytes
+			want := models.Response{Status: "success", Message: strconv.Itoa(i) + strings.Repeat("x", i%7), Time: time.Unix(int64(i), 0).UTC()}
+
+			rec := httptest.NewRecorder()
+			h.writeJSON(rec, http.StatusOK, want)
+
+			var got models.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				errs <- err.Error()
+				return
+			}
+			if got.Message != want.Message || !got.Time.Equal(want.Time) {
+				errs <- "got " + got.Message + ", want " + want.Message
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// discardResponseWriter is a reusable ResponseWriter that drops the body, so
+// that benchmarks measure encoding rather than the recorder.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(statusCode int)  {}
+
+// BenchmarkWriteJSON compares writeJSON with encoding straight to the
+// ResponseWriter, as it did before responses were buffered, and with
+// buffering into a fresh buffer per response. Buffering lets an encoding
+// failure become a 500; the pool keeps it from costing allocations.
+func BenchmarkWriteJSON(b *testing.B) {
+	h := newTestHandler(b, nil)
+	response := models.Response{Status: "success", Message: "pong", Time: time.Now()}
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			h.writeJSON(w, http.StatusOK, response)
+		}
+	})
+
+	b.Run("unpooled buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var buf bytes.Buffer
+			json.NewEncoder(&buf).Encode(response)
+			w.Header().Set("Content-Type", h.contentType)
+			w.WriteHeader(http.StatusOK)
+			w.Write(buf.Bytes())
+		}
+	})
+
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			w.Header().Set("Content-Type", h.contentType)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(response)
+		}
+	})
+}
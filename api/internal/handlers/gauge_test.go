@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestServiceReadyGauge(t *testing.T) {
+	var criticalUp, optionalUp atomic.Bool
+	check := func(up *atomic.Bool) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if up.Load() {
+				return nil
+			}
+			return errors.New("down")
+		}
+	}
+
+	h := newTestHandler(t, []ReadinessCheck{
+		{Name: "db", Check: check(&criticalUp)},
+		{Name: "search", Check: check(&optionalUp), NonCritical: true},
+	})
+
+	steps := []struct {
+		critical, optional bool
+		wantCode           int
+		wantStatus         string
+		wantGauge          float64
+	}{
+		{true, true, http.StatusOK, "ready", metrics.ReadyStateReady},
+		{true, false, http.StatusOK, "degraded", metrics.ReadyStateDegraded},
+		{false, true, http.StatusServiceUnavailable, "not_ready", metrics.ReadyStateNotReady},
+		{false, false, http.StatusServiceUnavailable, "not_ready", metrics.ReadyStateNotReady},
+		{true, true, http.StatusOK, "ready", metrics.ReadyStateReady},
+	}
+
+	for i, step := range steps {
+		criticalUp.Store(step.critical)
+		optionalUp.Store(step.optional)
+
+		code, body := getReady(t, h)
+		if code != step.wantCode || body.Status != step.wantStatus {
+			t.Errorf("step %d: got %d %q, want %d %q", i, code, body.Status, step.wantCode, step.wantStatus)
+		}
+		if got := testutil.ToFloat64(metrics.ServiceReady); got != step.wantGauge {
+			t.Errorf("step %d: service_ready = %v, want %v", i, got, step.wantGauge)
+		}
+	}
+}
@@ -1,78 +1,460 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
 	"github.com/arifjehoh/orchestrated-ping/internal/models"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 type Handler struct {
 	logger    *slog.Logger
 	startTime time.Time
+	// readySlots limits concurrent readiness checks, nil when unlimited
+	readySlots chan struct{}
+	// deprecations maps route patterns to upgrade notices
+	deprecations map[string]string
+	contentType  string
+	// minimalProbeStatus is the empty-body success status for probes, zero
+	// when probes respond with a full body
+	minimalProbeStatus int
+	// started is set once initialization has completed
+	started atomic.Bool
+	// checks are run by the readiness probe, each bounded by checkTimeout
+	checks       []ReadinessCheck
+	checkTimeout time.Duration
+	// checkDeps holds the indexes of each check's prerequisites
+	checkDeps  [][]int
+	checkCache checkCache
+	// successThreshold and failureThreshold debounce readiness flips
+	successThreshold int
+	failureThreshold int
+	// readiness is the outcome of the last readiness evaluation
+	readiness readinessState
+	// watchdog backs the liveness probe
+	watchdog *watchdog
+	// now is the clock for readiness check caching and /ping/clock,
+	// replaceable in tests
+	now func() time.Time
 }
 
-func New(logger *slog.Logger, startTime time.Time) *Handler {
-	return &Handler{
-		logger:    logger,
-		startTime: startTime,
+// New creates the handlers. It panics if the readiness checks depend on
+// unknown checks or on each other in a cycle.
+func New(cfg *config.Config, logger *slog.Logger, startTime time.Time, checks []ReadinessCheck) *Handler {
+	h := &Handler{
+		logger:           logger,
+		startTime:        startTime,
+		checks:           checks,
+		checkTimeout:     cfg.Probe.CheckTimeout,
+		checkDeps:        resolveDependencies(checks),
+		checkCache:       checkCache{results: make([]cachedResult, len(checks))},
+		now:              time.Now,
+		successThreshold: cfg.Probe.SuccessThreshold,
+		failureThreshold: cfg.Probe.FailureThreshold,
+		watchdog:         newWatchdog(cfg.Probe.WatchdogInterval, cfg.Probe.StallThreshold),
+		deprecations:     cfg.API.Deprecations,
+		contentType:      cfg.API.JSONContentType(),
 	}
+
+	if cfg.Probe.MinimalBody {
+		h.minimalProbeStatus = cfg.Probe.MinimalStatus
+	}
+
+	if cfg.Probe.ReadyMaxConcurrency > 0 {
+		h.readySlots = make(chan struct{}, cfg.Probe.ReadyMaxConcurrency)
+	}
+
+	return h
 }
 
 func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
-	h.logger.Debug("ping request received",
+	h.logger.DebugContext(r.Context(), "ping request received",
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
+	now := time.Now()
+	meta := h.meta(w, r)
+	if meta == nil {
+		h.respond(w, r, http.StatusOK, pongResponse{time: now})
+		return
+	}
+
 	response := models.Response{
 		Status:  "success",
 		Message: "pong",
+		Time:    now,
+		Meta:    meta,
+	}
+
+	h.respond(w, r, http.StatusOK, response)
+}
+
+// Clock reports the time the request was received alongside the client's
+// own timestamp from the "t" query parameter, so clients can estimate clock
+// skew from the round trip.
+func (h *Handler) Clock(w http.ResponseWriter, r *http.Request) {
+	received := h.now()
+
+	h.logger.DebugContext(r.Context(), "clock request received",
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	response := models.ClockResponse{
+		Status:     "success",
+		ServerTime: received,
+		ClientTime: r.URL.Query().Get("t"),
+		Meta:       h.meta(w, r),
+	}
+
+	h.respond(w, r, http.StatusOK, response)
+}
+
+// MarkStarted records that initialization has completed, after which the
+// startup probe succeeds and liveness/readiness probes take over.
+func (h *Handler) MarkStarted() {
+	h.started.Store(true)
+}
+
+func (h *Handler) Startup(w http.ResponseWriter, r *http.Request) {
+	h.logger.DebugContext(r.Context(), "startup check",
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	if !h.started.Load() {
+		h.respond(w, r, http.StatusServiceUnavailable, models.Response{
+			Status:  "starting",
+			Message: "application is still initializing",
+			Time:    time.Now(),
+			Meta:    h.meta(w, r),
+		})
+		return
+	}
+
+	response := models.Response{
+		Status:  "started",
+		Message: "application has finished initializing",
+		Time:    time.Now(),
+		Meta:    h.meta(w, r),
+	}
+
+	h.respond(w, r, http.StatusOK, response)
+}
+
+// RunWatchdog keeps the liveness watchdog ticking until ctx is cancelled. It
+// should run for the lifetime of the process.
+func (h *Handler) RunWatchdog(ctx context.Context) {
+	h.watchdog.run(ctx)
+}
+
+// Live reports whether the process is still responsive, based on the
+// liveness watchdog having ticked recently.
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	sinceLastBeat := h.watchdog.sinceLastBeat()
+
+	h.logger.DebugContext(r.Context(), "liveness check",
+		slog.Duration("duration", sinceLastBeat),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	if h.watchdog.stalled() {
+		h.logger.ErrorContext(r.Context(), "liveness watchdog stalled",
+			slog.Duration("duration", sinceLastBeat),
+		)
+		h.recordLiveness(r.Context(), liveStateStalled, sinceLastBeat)
+		h.respondProbe(w, r, http.StatusServiceUnavailable, models.Response{
+			Status:  "stalled",
+			Message: "liveness watchdog has not ticked since " + sinceLastBeat.Round(time.Millisecond).String(),
+			Time:    time.Now(),
+			Meta:    h.meta(w, r),
+		})
+		return
+	}
+	h.recordLiveness(r.Context(), liveStateAlive, sinceLastBeat)
+
+	response := models.Response{
+		Status:  "alive",
+		Message: "application is responsive",
 		Time:    time.Now(),
+		Meta:    h.meta(w, r),
 	}
 
-	h.writeJSON(w, http.StatusOK, response)
+	h.respondProbe(w, r, http.StatusOK, response)
+}
+
+// recordLiveness logs when the state reported by the liveness probe changes.
+func (h *Handler) recordLiveness(ctx context.Context, state string, sinceLastBeat time.Duration) {
+	if previous := h.watchdog.report(state); previous != state {
+		h.logger.InfoContext(ctx, "liveness state changed",
+			slog.String("previous_state", previous),
+			slog.String("state", state),
+			slog.Duration("duration", sinceLastBeat),
+		)
+	}
 }
 
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
-	uptime := time.Since(h.startTime).String()
+	// Both representations derive from one measurement so they agree
+	elapsed := time.Since(h.startTime)
+	uptime := elapsed.String()
 
-	h.logger.Debug("health check",
+	h.logger.DebugContext(r.Context(), "health check",
 		slog.String("uptime", uptime),
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
 	response := models.HealthResponse{
-		Status: "healthy",
-		Uptime: uptime,
+		Status:        "healthy",
+		Uptime:        uptime,
+		UptimeSeconds: elapsed.Seconds(),
+		Meta:          h.meta(w, r),
 	}
 
-	h.writeJSON(w, http.StatusOK, response)
+	h.respondProbe(w, r, http.StatusOK, response)
 }
 
 func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
-	h.logger.Debug("readiness check",
+	if h.readySlots != nil {
+		select {
+		case h.readySlots <- struct{}{}:
+			defer func() { <-h.readySlots }()
+		default:
+			h.logger.WarnContext(r.Context(), "readiness check rejected, too many in flight",
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+			h.respondProbe(w, r, http.StatusServiceUnavailable, models.ReadinessResponse{
+				Status:  "busy",
+				Message: "too many concurrent readiness checks",
+				Time:    time.Now(),
+			})
+			return
+		}
+	}
+
+	h.logger.DebugContext(r.Context(), "readiness check",
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
-	response := models.Response{
+	// Failures within the hysteresis threshold are reported without
+	// changing the outcome
+	state, failures := h.evaluate(r.Context())
+	switch state {
+	case readyStateNotReady:
+		h.respondProbe(w, r, http.StatusServiceUnavailable, models.ReadinessResponse{
+			Status:   "not_ready",
+			Message:  "readiness checks failed",
+			Time:     time.Now(),
+			Failures: failures,
+			Meta:     h.meta(w, r),
+		})
+		return
+	case readyStateDegraded:
+		h.respondProbe(w, r, http.StatusOK, models.ReadinessResponse{
+			Status:   "degraded",
+			Message:  "application is serving traffic with failing checks",
+			Time:     time.Now(),
+			Failures: failures,
+			Meta:     h.meta(w, r),
+		})
+		return
+	}
+
+	response := models.ReadinessResponse{
 		Status:  "ready",
 		Message: "application is ready to serve traffic",
 		Time:    time.Now(),
+		Meta:    h.meta(w, r),
 	}
 
-	h.writeJSON(w, http.StatusOK, response)
+	h.respondProbe(w, r, http.StatusOK, response)
 }
 
-func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+// meta attaches the deprecation notice configured for the matched route, both
+// as an X-API-Deprecation header and as a body warning. It returns nil when
+// the route has no notice so the _meta field is omitted.
+func (h *Handler) meta(w http.ResponseWriter, r *http.Request) *models.Meta {
+	notice, ok := h.deprecations[ownRoutePattern(r)]
+	if !ok {
+		return nil
+	}
+
+	w.Header().Set("X-API-Deprecation", notice)
+	return &models.Meta{Warnings: []string{notice}}
+}
+
+// ownRoutePattern returns the pattern matched within the innermost router,
+// which leaves out the prefix the routes are mounted under.
+func ownRoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || len(rctx.RoutePatterns) == 0 {
+		return ""
+	}
+	return rctx.RoutePatterns[len(rctx.RoutePatterns)-1]
+}
+
+func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "route not found",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	response := models.ErrorResponse{
+		Status:  "error",
+		Error:   http.StatusText(http.StatusNotFound),
+		Message: "no route matches " + r.URL.Path,
+	}
+
+	h.respond(w, r, http.StatusNotFound, response)
+}
+
+func (h *Handler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	h.logger.InfoContext(r.Context(), "method not allowed",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	response := models.ErrorResponse{
+		Status:  "error",
+		Error:   http.StatusText(http.StatusMethodNotAllowed),
+		Message: "method " + r.Method + " is not allowed on " + r.URL.Path,
+	}
+
+	if allowed := allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	h.respond(w, r, http.StatusMethodNotAllowed, response)
+}
+
+// routingMethods are the methods probed when listing those a route allows.
+var routingMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// allowedMethods returns the methods registered for the request path, as
+// required in the Allow header of a 405 response.
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	var allowed []string
+	for _, method := range routingMethods {
+		if rctx.Routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeText = "text/plain"
+)
+
+// offeredTypes are the media types handlers can respond with, the first
+// being the default. textOfferedTypes adds plain text for responses that
+// have a plain-text form.
+var (
+	offeredTypes     = []string{mediaTypeJSON}
+	textOfferedTypes = []string{mediaTypeJSON, mediaTypeText}
+)
+
+// plainTexter is implemented by responses with a plain-text representation,
+// for clients such as uptime checkers that only match strings.
+type plainTexter interface {
+	PlainText() string
+}
+
+// respond writes data in the representation negotiated from the request's
+// Accept header, falling back to JSON.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	offers := offeredTypes
+	text, hasText := data.(plainTexter)
+	if hasText {
+		offers = textOfferedTypes
+	}
+
+	switch negotiate(r.Header.Get("Accept"), offers) {
+	case mediaTypeJSON:
+		h.writeJSON(w, statusCode, data)
+	case mediaTypeText:
+		h.writeText(w, statusCode, text.PlainText())
+	}
+}
+
+// respondProbe is respond for health probes, which may be configured to reply
+// with the status code alone for monitors that ignore the body.
+func (h *Handler) respondProbe(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	if h.minimalProbeStatus == 0 {
+		h.respond(w, r, statusCode, data)
+		return
+	}
+
+	if statusCode == http.StatusOK {
+		statusCode = h.minimalProbeStatus
+	}
 	w.WriteHeader(statusCode)
+}
+
+// bufferPool reuses encoding buffers across responses to cut allocations.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// writeJSON encodes data into a pooled buffer before writing anything, so an
+// encoding failure can still be reported with a 500 status. Responses that
+// implement jsonAppender skip encoding/json.
+func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	if a, ok := data.(jsonAppender); ok {
+		buf.Write(a.AppendJSON(buf.AvailableBuffer()))
+	} else if err := json.NewEncoder(buf).Encode(data); err != nil {
 		h.logger.Error("failed to encode response",
 			slog.String("error", err.Error()),
 		)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", h.contentType)
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		h.logger.Error("failed to write response",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (h *Handler) writeText(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", mediaTypeText+"; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if _, err := io.WriteString(w, text); err != nil {
+		h.logger.Error("failed to write response",
+			slog.String("error", err.Error()),
+		)
 	}
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadinessHysteresis(t *testing.T) {
+	t.Setenv("READINESS_SUCCESS_THRESHOLD", "3")
+	t.Setenv("READINESS_FAILURE_THRESHOLD", "2")
+
+	var healthy atomic.Bool
+	h := newTestHandler(t, []ReadinessCheck{{Name: "db", Check: func(ctx context.Context) error {
+		if healthy.Load() {
+			return nil
+		}
+		return errors.New("db down")
+	}}})
+
+	steps := []struct {
+		healthy bool
+		want    int
+	}{
+		// The first evaluation is taken as is
+		{true, http.StatusOK},
+		// One failure is within the failure threshold of 2
+		{false, http.StatusOK},
+		{true, http.StatusOK},
+		{false, http.StatusOK},
+		{false, http.StatusServiceUnavailable},
+		// Three consecutive successes are needed to recover
+		{true, http.StatusServiceUnavailable},
+		{true, http.StatusServiceUnavailable},
+		{false, http.StatusServiceUnavailable},
+		{true, http.StatusServiceUnavailable},
+		{true, http.StatusServiceUnavailable},
+		{true, http.StatusOK},
+	}
+
+	for i, step := range steps {
+		healthy.Store(step.healthy)
+		code, body := getReady(t, h)
+		if code != step.want {
+			t.Fatalf("step %d (healthy=%v): status = %d, want %d", i, step.healthy, code, step.want)
+		}
+		if !step.healthy && len(body.Failures) != 1 {
+			t.Errorf("step %d: failures = %+v, want the failing check listed", i, body.Failures)
+		}
+	}
+}
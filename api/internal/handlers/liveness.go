@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	liveStateUnknown = "unknown"
+	liveStateAlive   = "alive"
+	liveStateStalled = "stalled"
+)
+
+// watchdog records heartbeats from a ticking goroutine. A heartbeat older than
+// the stall threshold means the runtime could not schedule it, so the process
+// is considered wedged.
+type watchdog struct {
+	interval  time.Duration
+	threshold time.Duration
+	// lastBeat is the time of the last heartbeat in Unix nanoseconds
+	lastBeat atomic.Int64
+
+	mu sync.Mutex
+	// reported is the state last reported by the liveness probe
+	reported string
+}
+
+func newWatchdog(interval, threshold time.Duration) *watchdog {
+	w := &watchdog{
+		interval:  interval,
+		threshold: threshold,
+	}
+	w.beat()
+	return w
+}
+
+func (w *watchdog) beat() {
+	w.lastBeat.Store(time.Now().UnixNano())
+}
+
+// run records a heartbeat every interval until ctx is cancelled.
+func (w *watchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.beat()
+		}
+	}
+}
+
+// sinceLastBeat returns how long ago the last heartbeat was recorded.
+func (w *watchdog) sinceLastBeat() time.Duration {
+	return time.Since(time.Unix(0, w.lastBeat.Load()))
+}
+
+// stalled reports whether the heartbeat is older than the threshold.
+func (w *watchdog) stalled() bool {
+	return w.sinceLastBeat() > w.threshold
+}
+
+// report records the state reported by the liveness probe and returns the
+// previously reported one.
+func (w *watchdog) report(state string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous := w.reported
+	if previous == "" {
+		previous = liveStateUnknown
+	}
+	w.reported = state
+	return previous
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func getLive(t *testing.T, h *Handler) (int, models.Response) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.Live(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	var body models.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestLive(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	if code, body := getLive(t, h); code != http.StatusOK || body.Status != "alive" {
+		t.Errorf("got %d %+v", code, body)
+	}
+
+	// Simulate a watchdog that has not been scheduled for a while
+	h.watchdog.lastBeat.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	if code, body := getLive(t, h); code != http.StatusServiceUnavailable || body.Status != "stalled" {
+		t.Errorf("got %d %+v", code, body)
+	}
+}
+
+func TestWatchdogRecovers(t *testing.T) {
+	t.Setenv("LIVENESS_WATCHDOG_INTERVAL", "5ms")
+	h := newTestHandler(t, nil)
+	h.watchdog.lastBeat.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.RunWatchdog(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for h.watchdog.stalled() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if code, body := getLive(t, h); code != http.StatusOK {
+		t.Errorf("got %d %+v after the watchdog ticked", code, body)
+	}
+}
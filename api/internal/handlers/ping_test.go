@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestClock(t *testing.T) {
+	h := newTestHandler(t, nil)
+	clock := &fakeClock{now: time.Date(2025, 12, 22, 10, 30, 0, 123456789, time.UTC)}
+	h.now = clock.Now
+
+	tests := []struct {
+		query      string
+		wantClient string
+	}{
+		{query: "?t=2025-12-22T10:29:59.900Z", wantClient: "2025-12-22T10:29:59.900Z"},
+		{query: "?t=1766399399900", wantClient: "1766399399900"},
+		{query: "", wantClient: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Clock(rec, httptest.NewRequest(http.MethodGet, "/ping/clock"+tt.query, nil))
+
+			var body models.ClockResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if rec.Code != http.StatusOK || body.Status != "success" {
+				t.Errorf("got %d %+v", rec.Code, body)
+			}
+			if !body.ServerTime.Equal(clock.Now()) {
+				t.Errorf("server_time = %s, want %s", body.ServerTime, clock.Now())
+			}
+			if body.ClientTime != tt.wantClient {
+				t.Errorf("client_time = %q, want %q", body.ClientTime, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestDeprecationNotice(t *testing.T) {
+	t.Setenv("API_DEPRECATIONS", "/ping=use /v2/ping;/ping/clock=removed in 2.0")
+	h := newTestHandler(t, nil)
+
+	r := chi.NewRouter()
+	r.Get("/ping", h.Ping)
+	r.Get("/ping/clock", h.Clock)
+	r.Get("/health", h.Health)
+
+	for path, want := range map[string]string{
+		"/ping":       "use /v2/ping",
+		"/ping/clock": "removed in 2.0",
+		"/health":     "",
+	} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if got := rec.Header().Get("X-API-Deprecation"); got != want {
+				t.Errorf("X-API-Deprecation = %q, want %q", got, want)
+			}
+
+			var body struct {
+				Meta *models.Meta `json:"_meta"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			switch {
+			case want == "" && body.Meta != nil:
+				t.Errorf("_meta = %+v, want none", body.Meta)
+			case want != "" && (body.Meta == nil || len(body.Meta.Warnings) != 1 || body.Meta.Warnings[0] != want):
+				t.Errorf("_meta = %+v, want warning %q", body.Meta, want)
+			}
+		})
+	}
+}
+
+func TestPingContentNegotiation(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		wantBody string
+	}{
+		{name: "text", accept: "text/plain", wantBody: "pong\n"},
+		{name: "json", accept: "application/json"},
+		{name: "no accept header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.Ping(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			wantType := h.contentType
+			if tt.wantBody != "" {
+				wantType = "text/plain; charset=utf-8"
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != wantType {
+				t.Errorf("Content-Type = %q, want %q", ct, wantType)
+			}
+
+			if tt.wantBody != "" {
+				if got := rec.Body.String(); got != tt.wantBody {
+					t.Errorf("body = %q, want %q", got, tt.wantBody)
+				}
+				return
+			}
+
+			var body models.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if body.Status != "success" || body.Message != "pong" {
+				t.Errorf("body = %+v, want a success pong", body)
+			}
+		})
+	}
+}
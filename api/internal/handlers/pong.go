@@ -0,0 +1,33 @@
+package handlers
+
+import "time"
+
+// jsonAppender is implemented by fixed-shape responses that encode
+// themselves without reflection. The output must match encoding/json's,
+// trailing newline included.
+type jsonAppender interface {
+	AppendJSON(b []byte) []byte
+}
+
+// pongPrefix and pongSuffix surround the timestamp of the /ping reply.
+var (
+	pongPrefix = []byte(`{"status":"success","message":"pong","time":"`)
+	pongSuffix = []byte("\"}\n")
+)
+
+// pongResponse is the /ping reply when no _meta is attached. It encodes to
+// the same bytes as the equivalent models.Response, with only the timestamp
+// formatted per request.
+type pongResponse struct {
+	time time.Time
+}
+
+func (p pongResponse) AppendJSON(b []byte) []byte {
+	b = append(b, pongPrefix...)
+	b = p.time.AppendFormat(b, time.RFC3339Nano)
+	return append(b, pongSuffix...)
+}
+
+func (p pongResponse) PlainText() string {
+	return "pong\n"
+}
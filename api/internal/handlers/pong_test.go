@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func TestPongResponseMatchesEncodingJSON(t *testing.T) {
+	times := []time.Time{
+		time.Date(2025, 12, 22, 10, 30, 0, 123456789, time.UTC),
+		time.Date(2025, 12, 22, 10, 30, 0, 0, time.UTC),
+		time.Date(2025, 12, 22, 10, 30, 0, 120000000, time.UTC),
+		time.Date(2025, 12, 22, 10, 30, 0, 5, time.FixedZone("CEST", 2*60*60)),
+		time.Date(2025, 12, 22, 10, 30, 0, 0, time.FixedZone("", -(3*60*60+30*60))),
+		time.Date(2025, 12, 22, 10, 30, 0, 0, time.FixedZone("", 5*60*60+45*60+15)),
+		time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Now(),
+	}
+
+	for _, tm := range times {
+		t.Run(tm.String(), func(t *testing.T) {
+			var want bytes.Buffer
+			if err := json.NewEncoder(&want).Encode(models.Response{Status: "success", Message: "pong", Time: tm}); err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+
+			if got := (pongResponse{time: tm}).AppendJSON(nil); !bytes.Equal(got, want.Bytes()) {
+				t.Errorf("AppendJSON() = %s, want %s", got, want.Bytes())
+			}
+		})
+	}
+}
+
+func BenchmarkPongResponse(b *testing.B) {
+	now := time.Now()
+
+	b.Run("AppendJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 128)
+		for range b.N {
+			buf = pongResponse{time: now}.AppendJSON(buf[:0])
+		}
+	})
+
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for range b.N {
+			buf.Reset()
+			json.NewEncoder(&buf).Encode(models.Response{Status: "success", Message: "pong", Time: now})
+		}
+	})
+}
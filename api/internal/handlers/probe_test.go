@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMinimalProbeBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		checks     []ReadinessCheck
+		path       string
+		wantStatus int
+	}{
+		{"health default status", "", nil, "/health", http.StatusNoContent},
+		{"health configured status", "200", nil, "/health", http.StatusOK},
+		{"livez", "", nil, "/livez", http.StatusNoContent},
+		{"ready passing", "", []ReadinessCheck{passing("db")}, "/ready", http.StatusNoContent},
+		{"ready failing keeps status", "", []ReadinessCheck{failing("db")}, "/ready", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MINIMAL_PROBE_BODY", "true")
+			if tt.status != "" {
+				t.Setenv("MINIMAL_PROBE_STATUS", tt.status)
+			}
+			h := newTestHandler(t, tt.checks)
+			probes := map[string]http.HandlerFunc{"/health": h.Health, "/livez": h.Live, "/ready": h.Ready}
+
+			rec := httptest.NewRecorder()
+			probes[tt.path](rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("body = %q, want empty", rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "" {
+				t.Errorf("Content-Type = %q, want none", ct)
+			}
+		})
+	}
+}
+
+func TestMinimalProbeBodyDisabled(t *testing.T) {
+	t.Setenv("MINIMAL_PROBE_STATUS", strconv.Itoa(http.StatusNoContent))
+	h := newTestHandler(t, nil)
+
+	rec := httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Errorf("got %d with %d bytes, want 200 with a body", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestHealthUptime(t *testing.T) {
+	h := newTestHandler(t, nil)
+	h.startTime = time.Now().Add(-90 * time.Second)
+
+	rec := httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	seconds, ok := body["uptime_seconds"].(float64)
+	if !ok {
+		t.Fatalf("uptime_seconds = %#v, want a number", body["uptime_seconds"])
+	}
+	uptime, ok := body["uptime"].(string)
+	if !ok {
+		t.Fatalf("uptime = %#v, want a string", body["uptime"])
+	}
+	parsed, err := time.ParseDuration(uptime)
+	if err != nil {
+		t.Fatalf("parsing uptime %q: %v", uptime, err)
+	}
+
+	if seconds < 90 {
+		t.Errorf("uptime_seconds = %v, want at least 90", seconds)
+	}
+	if parsed.Seconds() != seconds {
+		t.Errorf("uptime %q = %vs, want it to match uptime_seconds %v", uptime, parsed.Seconds(), seconds)
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+// ReadinessCheck is a named dependency check run by the readiness probe, such
+// as a database ping or a downstream HTTP call. Check should return promptly
+// once ctx is done.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// DependsOn names checks that must pass before this one runs. When one
+	// of them does not pass, this check is skipped.
+	DependsOn []string
+	// NonCritical checks only degrade readiness when they do not pass,
+	// leaving the service in rotation
+	NonCritical bool
+	// TTL caches the check's result for this long so that expensive checks
+	// do not run on every probe. Zero runs the check every time.
+	TTL time.Duration
+}
+
+// checkCache holds the last result of each readiness check with a TTL.
+type checkCache struct {
+	mu      sync.Mutex
+	results []cachedResult
+}
+
+type cachedResult struct {
+	err error
+	at  time.Time
+	ok  bool
+}
+
+// runCached returns the check's cached result while it is within the TTL,
+// and otherwise runs the check and caches the new result, unless the caller
+// gave up on the probe before it finished.
+func (h *Handler) runCached(ctx context.Context, i int) error {
+	check := h.checks[i]
+	if check.TTL <= 0 {
+		return runCheck(ctx, check, h.checkTimeout)
+	}
+
+	h.checkCache.mu.Lock()
+	cached := h.checkCache.results[i]
+	h.checkCache.mu.Unlock()
+
+	if cached.ok && h.now().Sub(cached.at) < check.TTL {
+		return cached.err
+	}
+
+	err := runCheck(ctx, check, h.checkTimeout)
+
+	// A probe aborted by its caller says nothing about the dependency
+	if ctx.Err() != nil {
+		return err
+	}
+
+	h.checkCache.mu.Lock()
+	h.checkCache.results[i] = cachedResult{err: err, at: h.now(), ok: true}
+	h.checkCache.mu.Unlock()
+
+	return err
+}
+
+const (
+	readyStateUnknown  = "unknown"
+	readyStateReady    = "ready"
+	readyStateDegraded = "degraded"
+	readyStateNotReady = "not_ready"
+)
+
+// readinessState tracks the reported readiness and how many consecutive
+// evaluations have disagreed with whether the service is in rotation, so that
+// it only flips once the configured threshold is reached rather than on every
+// blip.
+type readinessState struct {
+	mu    sync.Mutex
+	state string
+	// up is whether the service is in rotation, i.e. ready or degraded
+	up     bool
+	streak int
+}
+
+// recordReadiness applies the outcome of a readiness evaluation, updates the
+// service_ready gauge and logs when the reported state changes. Whether the
+// service is in rotation follows passed, meaning no critical check failed,
+// with hysteresis; the first evaluation is taken as is. While in rotation
+// the state is degraded if any check did not pass. checks names the checks
+// that did not pass. It returns the reported state.
+func (h *Handler) recordReadiness(ctx context.Context, passed bool, checks []string) string {
+	threshold := h.failureThreshold
+	if passed {
+		threshold = h.successThreshold
+	}
+
+	h.readiness.mu.Lock()
+	previous := h.readiness.state
+	switch {
+	case previous == "" || h.readiness.up == passed:
+		h.readiness.up = passed
+		h.readiness.streak = 0
+	default:
+		h.readiness.streak++
+		if h.readiness.streak >= threshold {
+			h.readiness.up = passed
+			h.readiness.streak = 0
+		}
+	}
+
+	switch {
+	case !h.readiness.up:
+		h.readiness.state = readyStateNotReady
+	case len(checks) > 0:
+		h.readiness.state = readyStateDegraded
+	default:
+		h.readiness.state = readyStateReady
+	}
+	state := h.readiness.state
+	h.readiness.mu.Unlock()
+
+	switch state {
+	case readyStateReady:
+		metrics.ServiceReady.Set(metrics.ReadyStateReady)
+	case readyStateDegraded:
+		metrics.ServiceReady.Set(metrics.ReadyStateDegraded)
+	default:
+		metrics.ServiceReady.Set(metrics.ReadyStateNotReady)
+	}
+
+	if previous == "" {
+		previous = readyStateUnknown
+	}
+	if previous != state {
+		h.logger.InfoContext(ctx, "readiness state changed",
+			slog.String("previous_state", previous),
+			slog.String("state", state),
+			slog.Any("checks", checks),
+		)
+	}
+
+	return state
+}
+
+// runChecks runs all readiness checks concurrently, each bounded by
+// checkTimeout and served from cache within its TTL, and returns the ones
+// that did not pass in registration order. A check waits for its
+// prerequisites and is skipped if any of them did not pass.
+func (h *Handler) runChecks(ctx context.Context) []models.CheckFailure {
+	errs := make([]error, len(h.checks))
+	// skippedFor names the prerequisite that caused a check to be skipped
+	skippedFor := make([]string, len(h.checks))
+	done := make([]chan struct{}, len(h.checks))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, dep := range h.checkDeps[i] {
+				<-done[dep]
+				if errs[dep] != nil || skippedFor[dep] != "" {
+					skippedFor[i] = h.checks[dep].Name
+					return
+				}
+			}
+
+			errs[i] = h.runCached(ctx, i)
+		}()
+	}
+	wg.Wait()
+
+	var failures []models.CheckFailure
+	for i, check := range h.checks {
+		switch {
+		case skippedFor[i] != "":
+			failures = append(failures, models.CheckFailure{
+				Name:   check.Name,
+				Status: models.CheckStatusSkipped,
+				Error:  "prerequisite " + skippedFor[i] + " did not pass",
+			})
+		case errs[i] != nil:
+			failures = append(failures, models.CheckFailure{
+				Name:   check.Name,
+				Status: models.CheckStatusFailed,
+				Error:  errs[i].Error(),
+			})
+		}
+	}
+	return failures
+}
+
+// EvaluateReadiness runs the readiness checks once and records the outcome,
+// so that the service_ready gauge reflects the dependencies before the first
+// probe arrives. It reports whether the service is in rotation, i.e. ready or
+// degraded.
+func (h *Handler) EvaluateReadiness(ctx context.Context) bool {
+	state, _ := h.evaluate(ctx)
+	return state != readyStateNotReady
+}
+
+// evaluate runs the readiness checks and records the outcome, returning the
+// reported state and the checks that did not pass.
+func (h *Handler) evaluate(ctx context.Context) (string, []models.CheckFailure) {
+	failures := h.runChecks(ctx)
+	failed, passed := h.classifyFailures(failures)
+	return h.recordReadiness(ctx, passed, failed), failures
+}
+
+// classifyFailures returns the names of the checks that did not pass, and
+// whether every critical check passed.
+func (h *Handler) classifyFailures(failures []models.CheckFailure) ([]string, bool) {
+	critical := make(map[string]bool, len(h.checks))
+	for _, check := range h.checks {
+		critical[check.Name] = !check.NonCritical
+	}
+
+	names := make([]string, 0, len(failures))
+	passed := true
+	for _, failure := range failures {
+		names = append(names, failure.Name)
+		if critical[failure.Name] {
+			passed = false
+		}
+	}
+	return names, passed
+}
+
+// resolveDependencies maps each check's DependsOn names to check indexes. It
+// panics when a name is unknown or the dependencies form a cycle, as checks
+// are registered in code and such a mistake should fail at startup.
+func resolveDependencies(checks []ReadinessCheck) [][]int {
+	index := make(map[string]int, len(checks))
+	for i, check := range checks {
+		index[check.Name] = i
+	}
+
+	deps := make([][]int, len(checks))
+	for i, check := range checks {
+		for _, name := range check.DependsOn {
+			dep, ok := index[name]
+			if !ok {
+				panic(fmt.Sprintf("readiness check %q depends on unknown check %q", check.Name, name))
+			}
+			deps[i] = append(deps[i], dep)
+		}
+	}
+
+	// Depth-first search for a path back to a check still being visited
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(checks))
+	var visit func(i int)
+	visit = func(i int) {
+		switch marks[i] {
+		case visiting:
+			panic(fmt.Sprintf("readiness check %q depends on itself", checks[i].Name))
+		case visited:
+			return
+		}
+		marks[i] = visiting
+		for _, dep := range deps[i] {
+			visit(dep)
+		}
+		marks[i] = visited
+	}
+	for i := range checks {
+		visit(i)
+	}
+
+	return deps
+}
+
+// runCheck runs a single check, giving up when the timeout elapses even if
+// the check ignores its context.
+func runCheck(ctx context.Context, check ReadinessCheck, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- check.Check(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
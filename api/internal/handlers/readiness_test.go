@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func newTestHandler(t testing.TB, checks []ReadinessCheck) *Handler {
+	t.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	return New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Now(), checks)
+}
+
+func passing(name string, deps ...string) ReadinessCheck {
+	return ReadinessCheck{Name: name, DependsOn: deps, Check: func(ctx context.Context) error { return nil }}
+}
+
+func failing(name string, deps ...string) ReadinessCheck {
+	return ReadinessCheck{Name: name, DependsOn: deps, Check: func(ctx context.Context) error { return errors.New(name + " down") }}
+}
+
+func getReady(t *testing.T, h *Handler) (int, models.ReadinessResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	var body models.ReadinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestReadyAllPass(t *testing.T) {
+	h := newTestHandler(t, []ReadinessCheck{passing("db"), passing("cache")})
+
+	code, body := getReady(t, h)
+	if code != http.StatusOK || body.Status != "ready" || len(body.Failures) != 0 {
+		t.Errorf("got %d %+v", code, body)
+	}
+}
+
+func TestReadyPartialFailure(t *testing.T) {
+	h := newTestHandler(t, []ReadinessCheck{passing("db"), failing("cache")})
+
+	code, body := getReady(t, h)
+	if code != http.StatusServiceUnavailable || body.Status != "not_ready" {
+		t.Fatalf("got %d %+v", code, body)
+	}
+
+	want := []models.CheckFailure{{Name: "cache", Status: models.CheckStatusFailed, Error: "cache down"}}
+	if !reflect.DeepEqual(body.Failures, want) {
+		t.Errorf("failures = %+v, want %+v", body.Failures, want)
+	}
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	t.Setenv("READINESS_CHECK_TIMEOUT", "20ms")
+
+	release := make(chan struct{})
+	defer close(release)
+
+	// The check ignores its context
+	h := newTestHandler(t, []ReadinessCheck{{Name: "slow", Check: func(ctx context.Context) error {
+		<-release
+		return nil
+	}}})
+
+	start := time.Now()
+	code, body := getReady(t, h)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("readiness took %s, want about the 20ms check timeout", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || len(body.Failures) != 1 || body.Failures[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("got %d %+v", code, body)
+	}
+}
+
+func TestReadyRunsChecksConcurrently(t *testing.T) {
+	// Each check waits for the other to start, so running them one after
+	// the other would time both out
+	var started sync.WaitGroup
+	started.Add(2)
+	barrier := func(name string) ReadinessCheck {
+		return ReadinessCheck{Name: name, Check: func(ctx context.Context) error {
+			started.Done()
+			waited := make(chan struct{})
+			go func() {
+				started.Wait()
+				close(waited)
+			}()
+			select {
+			case <-waited:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}}
+	}
+	h := newTestHandler(t, []ReadinessCheck{barrier("db"), barrier("cache")})
+
+	if code, body := getReady(t, h); code != http.StatusOK {
+		t.Errorf("got %d %+v", code, body)
+	}
+}
+
+func TestReadySkipsDependentsOfFailedPrerequisite(t *testing.T) {
+	var dependentRuns atomic.Int32
+	dependent := ReadinessCheck{Name: "api", DependsOn: []string{"db"}, Check: func(ctx context.Context) error {
+		dependentRuns.Add(1)
+		return nil
+	}}
+
+	h := newTestHandler(t, []ReadinessCheck{
+		failing("network"),
+		failing("db", "network"),
+		dependent,
+		passing("cache"),
+	})
+
+	code, body := getReady(t, h)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d", code)
+	}
+
+	want := []models.CheckFailure{
+		{Name: "network", Status: models.CheckStatusFailed, Error: "network down"},
+		{Name: "db", Status: models.CheckStatusSkipped, Error: "prerequisite network did not pass"},
+		{Name: "api", Status: models.CheckStatusSkipped, Error: "prerequisite db did not pass"},
+	}
+	if !reflect.DeepEqual(body.Failures, want) {
+		t.Errorf("failures = %+v, want %+v", body.Failures, want)
+	}
+	if n := dependentRuns.Load(); n != 0 {
+		t.Errorf("dependent check ran %d times, want 0", n)
+	}
+}
+
+func TestReadyRunsDependentsAfterPrerequisites(t *testing.T) {
+	var dbDone atomic.Bool
+	h := newTestHandler(t, []ReadinessCheck{
+		{Name: "api", DependsOn: []string{"db"}, Check: func(ctx context.Context) error {
+			if !dbDone.Load() {
+				return errors.New("ran before db")
+			}
+			return nil
+		}},
+		{Name: "db", Check: func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			dbDone.Store(true)
+			return nil
+		}},
+	})
+
+	if code, body := getReady(t, h); code != http.StatusOK {
+		t.Errorf("got %d %+v", code, body)
+	}
+}
+
+func TestResolveDependenciesPanics(t *testing.T) {
+	tests := map[string][]ReadinessCheck{
+		"unknown dependency": {passing("api", "db")},
+		"cycle":              {passing("a", "b"), passing("b", "c"), passing("c", "a")},
+		"self":               {passing("a", "a")},
+	}
+
+	for name, checks := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("resolveDependencies did not panic")
+				}
+			}()
+			resolveDependencies(checks)
+		})
+	}
+}
+
+func TestEvaluateReadiness(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		checks []ReadinessCheck
+		want   bool
+	}{
+		{"no checks", nil, true},
+		{"passing", []ReadinessCheck{passing("db")}, true},
+		{"failing", []ReadinessCheck{failing("db")}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, tt.checks)
+			if got := h.EvaluateReadiness(context.Background()); got != tt.want {
+				t.Errorf("EvaluateReadiness() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadyMaxConcurrency(t *testing.T) {
+	t.Setenv("READY_MAX_CONCURRENCY", "1")
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	h := newTestHandler(t, []ReadinessCheck{{Name: "slow", Check: func(ctx context.Context) error {
+		entered <- struct{}{}
+		<-release
+		return nil
+	}}})
+
+	first := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		first <- rec.Code
+	}()
+	<-entered
+
+	code, body := getReady(t, h)
+	if code != http.StatusServiceUnavailable || body.Status != "busy" {
+		t.Errorf("concurrent probe: got %d %+v, want 503 busy", code, body)
+	}
+
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first probe: status %d, want 200", code)
+	}
+
+	// The slot is released once the first probe completes
+	go func() { <-entered }()
+	if code, _ := getReady(t, h); code != http.StatusOK {
+		t.Errorf("later probe: status %d, want 200", code)
+	}
+}
@@ -0,0 +1,96 @@
+// Package readinesstest provides utilities for testing readiness logic
+// against dependencies that fail on demand.
+package readinesstest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+)
+
+// fault is the programmed behaviour of a single check.
+type fault struct {
+	err   error
+	delay time.Duration
+}
+
+// Injector wraps readiness checks so that tests can make specific checks
+// fail with chosen errors or respond slowly. Wrapped checks behave like the
+// originals until a fault is programmed for them. It is safe for concurrent
+// use by the probe and the test.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]fault
+}
+
+// NewInjector returns an Injector with no faults programmed.
+func NewInjector() *Injector {
+	return &Injector{faults: make(map[string]fault)}
+}
+
+// Wrap returns checks with each Check routed through the injector, ready to
+// be passed to handlers.New.
+func (i *Injector) Wrap(checks ...handlers.ReadinessCheck) []handlers.ReadinessCheck {
+	wrapped := make([]handlers.ReadinessCheck, len(checks))
+	for n, check := range checks {
+		next := check.Check
+		check.Check = func(ctx context.Context) error {
+			return i.run(ctx, check.Name, next)
+		}
+		wrapped[n] = check
+	}
+	return wrapped
+}
+
+// Fail makes the named check return err instead of running.
+func (i *Injector) Fail(name string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	f := i.faults[name]
+	f.err = err
+	i.faults[name] = f
+}
+
+// Delay makes the named check wait for d, or until its context is done,
+// before running or failing.
+func (i *Injector) Delay(name string, d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	f := i.faults[name]
+	f.delay = d
+	i.faults[name] = f
+}
+
+// Heal removes any fault programmed for the named check.
+func (i *Injector) Heal(name string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.faults, name)
+}
+
+func (i *Injector) run(ctx context.Context, name string, next func(context.Context) error) error {
+	i.mu.Lock()
+	f := i.faults[name]
+	i.mu.Unlock()
+
+	if f.delay > 0 {
+		timer := time.NewTimer(f.delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if f.err != nil {
+		return f.err
+	}
+	return next(ctx)
+}
@@ -0,0 +1,125 @@
+package readinesstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func passing(name string) handlers.ReadinessCheck {
+	return handlers.ReadinessCheck{Name: name, Check: func(ctx context.Context) error { return nil }}
+}
+
+func newHandler(t *testing.T, checks []handlers.ReadinessCheck) *handlers.Handler {
+	t.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	return handlers.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Now(), checks)
+}
+
+func getReady(t *testing.T, h *handlers.Handler) (int, models.ReadinessResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	var body models.ReadinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestInjectorDrivesReadiness(t *testing.T) {
+	t.Setenv("READINESS_CHECK_TIMEOUT", "50ms")
+
+	cache := passing("cache")
+	cache.NonCritical = true
+	injector := NewInjector()
+	h := newHandler(t, injector.Wrap(passing("db"), cache))
+
+	steps := []struct {
+		name       string
+		program    func()
+		wantCode   int
+		wantStatus string
+		wantFailed []models.CheckFailure
+	}{
+		{
+			name:       "no faults",
+			program:    func() {},
+			wantCode:   http.StatusOK,
+			wantStatus: "ready",
+		},
+		{
+			name:       "critical check fails",
+			program:    func() { injector.Fail("db", errors.New("connection refused")) },
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "not_ready",
+			wantFailed: []models.CheckFailure{{Name: "db", Status: models.CheckStatusFailed, Error: "connection refused"}},
+		},
+		{
+			name: "non-critical check too slow",
+			program: func() {
+				injector.Heal("db")
+				injector.Delay("cache", time.Second)
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: "degraded",
+			wantFailed: []models.CheckFailure{{Name: "cache", Status: models.CheckStatusFailed, Error: context.DeadlineExceeded.Error()}},
+		},
+		{
+			name:       "healed",
+			program:    func() { injector.Heal("cache") },
+			wantCode:   http.StatusOK,
+			wantStatus: "ready",
+		},
+	}
+
+	for _, step := range steps {
+		step.program()
+
+		code, body := getReady(t, h)
+		if code != step.wantCode || body.Status != step.wantStatus {
+			t.Errorf("%s: got %d %q, want %d %q", step.name, code, body.Status, step.wantCode, step.wantStatus)
+		}
+		if !reflect.DeepEqual(body.Failures, step.wantFailed) {
+			t.Errorf("%s: failures = %+v, want %+v", step.name, body.Failures, step.wantFailed)
+		}
+	}
+}
+
+func TestInjectorDelayThenRun(t *testing.T) {
+	runs := 0
+	injector := NewInjector()
+	checks := injector.Wrap(handlers.ReadinessCheck{Name: "db", Check: func(ctx context.Context) error {
+		runs++
+		return nil
+	}})
+	injector.Delay("db", 20*time.Millisecond)
+
+	start := time.Now()
+	if err := checks[0].Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Check() returned after %s, want the 20ms delay", elapsed)
+	}
+	if runs != 1 {
+		t.Errorf("wrapped check ran %d times, want 1 after the delay", runs)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// recordingHandler keeps the records it handles along with whether they
+// were logged with the request context.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+	withCtx []bool
+}
+
+func (rh *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (rh *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.records = append(rh.records, r)
+	rh.withCtx = append(rh.withCtx, ctx.Value(ctxKey{}) != nil)
+	return nil
+}
+
+func (rh *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return rh }
+func (rh *recordingHandler) WithGroup(string) slog.Handler      { return rh }
+
+// transitions returns the attributes of each record with the given message.
+func (rh *recordingHandler) transitions(t *testing.T, msg string) []map[string]string {
+	t.Helper()
+
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+
+	var found []map[string]string
+	for i, r := range rh.records {
+		if r.Message != msg {
+			continue
+		}
+		if !rh.withCtx[i] {
+			t.Errorf("%q logged without the request context", msg)
+		}
+		attrs := make(map[string]string)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.String()
+			return true
+		})
+		found = append(found, attrs)
+	}
+	return found
+}
+
+func probe(h http.HandlerFunc, path string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, true))
+	h(httptest.NewRecorder(), req)
+}
+
+func TestLivenessTransitionsLogged(t *testing.T) {
+	h := newTestHandler(t, nil)
+	rh := &recordingHandler{}
+	h.logger = slog.New(rh)
+
+	probe(h.Live, "/livez")
+	probe(h.Live, "/livez")
+
+	h.watchdog.lastBeat.Store(time.Now().Add(-time.Hour).UnixNano())
+	probe(h.Live, "/livez")
+	probe(h.Live, "/livez")
+
+	h.watchdog.beat()
+	probe(h.Live, "/livez")
+
+	got := rh.transitions(t, "liveness state changed")
+	want := [][2]string{
+		{liveStateUnknown, liveStateAlive},
+		{liveStateAlive, liveStateStalled},
+		{liveStateStalled, liveStateAlive},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transitions, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i]["previous_state"] != w[0] || got[i]["state"] != w[1] || got[i]["duration"] == "" {
+			t.Errorf("transition %d = %v, want %s -> %s", i, got[i], w[0], w[1])
+		}
+	}
+}
+
+func TestReadinessTransitionsLogged(t *testing.T) {
+	h := newTestHandler(t, []ReadinessCheck{passing("db")})
+	rh := &recordingHandler{}
+	h.logger = slog.New(rh)
+
+	probe(h.Ready, "/ready")
+	probe(h.Ready, "/ready")
+
+	got := rh.transitions(t, "readiness state changed")
+	if len(got) != 1 {
+		t.Fatalf("got %d transitions, want 1: %v", len(got), got)
+	}
+	if got[0]["previous_state"] != readyStateUnknown || got[0]["state"] != readyStateReady {
+		t.Errorf("transition = %v", got[0])
+	}
+}
@@ -0,0 +1,55 @@
+package jitter
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Jitter spreads periodic task intervals by a random ±fraction so replicas
+// started together do not hit downstream dependencies in lockstep.
+type Jitter struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	fraction float64
+}
+
+// New returns a Jitter varying intervals by up to ±fraction (0.1 is ±10%).
+// The RNG is injected so callers can seed it for reproducible intervals.
+func New(fraction float64, rng *rand.Rand) *Jitter {
+	return &Jitter{
+		rng:      rng,
+		fraction: fraction,
+	}
+}
+
+// Duration returns interval adjusted by a random offset within the jitter
+// bounds.
+func (j *Jitter) Duration(interval time.Duration) time.Duration {
+	if j.fraction <= 0 {
+		return interval
+	}
+
+	j.mu.Lock()
+	offset := (j.rng.Float64()*2 - 1) * j.fraction
+	j.mu.Unlock()
+
+	return interval + time.Duration(float64(interval)*offset)
+}
+
+// Run calls task every jittered interval until ctx is cancelled.
+func (j *Jitter) Run(ctx context.Context, interval time.Duration, task func()) {
+	timer := time.NewTimer(j.Duration(interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			task()
+			timer.Reset(j.Duration(interval))
+		}
+	}
+}
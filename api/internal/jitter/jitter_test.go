@@ -0,0 +1,71 @@
+package jitter
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDurationWithinBounds(t *testing.T) {
+	const interval = 10 * time.Second
+	j := New(0.1, rand.New(rand.NewPCG(1, 2)))
+
+	seen := make(map[time.Duration]bool)
+	for range 1000 {
+		d := j.Duration(interval)
+		if d < 9*time.Second || d > 11*time.Second {
+			t.Fatalf("Duration() = %s, want within ±10%% of %s", d, interval)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("only %d distinct intervals in 1000 draws", len(seen))
+	}
+}
+
+func TestDurationReproducible(t *testing.T) {
+	a := New(0.2, rand.New(rand.NewPCG(7, 7)))
+	b := New(0.2, rand.New(rand.NewPCG(7, 7)))
+
+	for i := range 10 {
+		if da, db := a.Duration(time.Minute), b.Duration(time.Minute); da != db {
+			t.Fatalf("draw %d: %s != %s with the same seed", i, da, db)
+		}
+	}
+}
+
+func TestDurationDisabled(t *testing.T) {
+	j := New(0, rand.New(rand.NewPCG(1, 2)))
+	if d := j.Duration(time.Minute); d != time.Minute {
+		t.Errorf("Duration() = %s, want the interval unchanged", d)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	j := New(0.1, rand.New(rand.NewPCG(1, 2)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		j.Run(ctx, time.Millisecond, func() { runs.Add(1) })
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runs.Load() < 3 {
+		t.Fatalf("task ran %d times, want at least 3", runs.Load())
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
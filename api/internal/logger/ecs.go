@@ -6,22 +6,34 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ECSHandler struct {
-	handler     slog.Handler
+	handler slog.Handler
+	writer  io.Writer
+	// mu serializes writes so concurrent records never interleave. It is
+	// shared by handlers derived through WithAttrs and WithGroup.
+	mu *sync.Mutex
+	// attrs are added to every record, with keys already prefixed by group
+	attrs       []slog.Attr
+	group       string
 	serviceName string
 	version     string
 }
 
-func NewECSHandler(w io.Writer, serviceName, version string) *ECSHandler {
+func NewECSHandler(w io.Writer, serviceName, version string, level slog.Leveler) *ECSHandler {
 	return &ECSHandler{
 		handler: slog.NewJSONHandler(w, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
+			Level: level,
 		}),
+		writer:      w,
+		mu:          &sync.Mutex{},
 		serviceName: serviceName,
 		version:     version,
 	}
@@ -42,20 +54,36 @@ func (h *ECSHandler) Handle(ctx context.Context, r slog.Record) error {
 	attrs["service.name"] = h.serviceName
 	attrs["service.version"] = h.version
 
-	r.Attrs(func(a slog.Attr) bool {
+	for _, a := range h.attrs {
 		h.mapAttribute(attrs, a.Key, a.Value.Any())
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		h.mapAttribute(attrs, h.group+a.Key, a.Value.Any())
 		return true
 	})
 
+	// An OpenTelemetry span supersedes the request ID as trace.id
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		if requestID, ok := attrs["trace.id"]; ok {
+			attrs["http.request.id"] = requestID
+		}
+		attrs["trace.id"] = sc.TraceID().String()
+		attrs["span.id"] = sc.SpanID().String()
+	}
+
 	b, err := json.Marshal(attrs)
 	if err != nil {
 		return err
 	}
+	b = append(b, '\n')
 
-	os.Stdout.Write(b)
-	os.Stdout.Write([]byte("\n"))
+	// Write each record, newline included, in a single call
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.writer.Write(b)
 
-	return nil
+	return err
 }
 
 func (h *ECSHandler) mapAttribute(attrs map[string]interface{}, key string, val interface{}) {
@@ -66,6 +94,10 @@ func (h *ECSHandler) mapAttribute(attrs map[string]interface{}, key string, val
 		attrs["url.path"] = val
 	case "status":
 		attrs["http.response.status_code"] = val
+	case "content_type":
+		attrs["http.response.mime_type"] = val
+	case "content_encoding":
+		attrs["http.response.content_encoding"] = val
 	case "bytes":
 		attrs["http.response.body.bytes"] = val
 	case "duration":
@@ -76,10 +108,22 @@ func (h *ECSHandler) mapAttribute(attrs map[string]interface{}, key string, val
 		}
 	case "remote_addr":
 		attrs["client.address"] = val
+	case "scheme":
+		attrs["url.scheme"] = val
+	case "host":
+		attrs["url.domain"] = val
+	case "protocol":
+		attrs["http.version"] = val
+	case "user_agent":
+		attrs["user_agent.original"] = val
+	case "referer":
+		attrs["http.request.referrer"] = val
 	case "request_id":
 		attrs["trace.id"] = val
 	case "error":
 		attrs["error.message"] = val
+	case "stack":
+		attrs["error.stack_trace"] = val
 	case "uptime":
 		attrs["event.uptime"] = val
 	case "port":
@@ -92,22 +136,55 @@ func (h *ECSHandler) mapAttribute(attrs map[string]interface{}, key string, val
 }
 
 func (h *ECSHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &ECSHandler{
-		handler:     h.handler.WithAttrs(attrs),
-		serviceName: h.serviceName,
-		version:     h.version,
+	h2 := h.clone()
+	h2.handler = h.handler.WithAttrs(attrs)
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, slog.Attr{Key: h.group + a.Key, Value: a.Value})
 	}
+	return h2
 }
 
 func (h *ECSHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.handler = h.handler.WithGroup(name)
+	h2.group = h.group + name + "."
+	return h2
+}
+
+func (h *ECSHandler) clone() *ECSHandler {
 	return &ECSHandler{
-		handler:     h.handler.WithGroup(name),
+		handler:     h.handler,
+		writer:      h.writer,
+		mu:          h.mu,
+		attrs:       slices.Clip(h.attrs),
+		group:       h.group,
 		serviceName: h.serviceName,
 		version:     h.version,
 	}
 }
 
 func New(cfg *config.Config) *slog.Logger {
-	handler := NewECSHandler(os.Stdout, cfg.Service.Name, cfg.Service.Version)
-	return slog.New(handler)
+	return newLogger(cfg, os.Stdout)
+}
+
+func newLogger(cfg *config.Config, w io.Writer) *slog.Logger {
+	if cfg.Log.Format == config.LogFormatText {
+		handler := slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level: cfg.Log.SlogLevel(),
+		})
+		return slog.New(handler).With(
+			slog.String("service", cfg.Service.Name),
+			slog.String("version", cfg.Service.Version),
+			slog.String("environment", cfg.Environment),
+		)
+	}
+
+	handler := NewECSHandler(w, cfg.Service.Name, cfg.Service.Version, cfg.Log.SlogLevel())
+	return slog.New(handler).With(
+		slog.String("environment", cfg.Environment),
+	)
 }
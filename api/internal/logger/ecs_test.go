@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+)
+
+// decodeLines parses each line written to buf as a JSON object.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestECSHandlerWritesToWriter(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewECSHandler(&buf, "orchestrated-ping", "1.0.0", slog.LevelInfo))
+
+	log.Info("request completed", slog.String("method", "GET"), slog.Int("status", 200))
+	log.Debug("dropped below the level")
+
+	records := decodeLines(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	record := records[0]
+	for key, want := range map[string]any{
+		"message":                   "request completed",
+		"log.level":                 "INFO",
+		"service.name":              "orchestrated-ping",
+		"service.version":           "1.0.0",
+		"http.request.method":       "GET",
+		"http.response.status_code": float64(200),
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+	if record["@timestamp"] == nil || record["ecs.version"] == nil {
+		t.Errorf("record misses @timestamp or ecs.version: %v", record)
+	}
+}
+
+func TestECSHandlerDerivedHandlersShareWriter(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewECSHandler(&buf, "orchestrated-ping", "1.0.0", slog.LevelInfo))
+
+	log.With(slog.String("component", "probe")).WithGroup("check").Info("ran", slog.String("name", "db"))
+
+	records := decodeLines(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	if records[0]["component"] != "probe" || records[0]["check.name"] != "db" {
+		t.Errorf("record = %v", records[0])
+	}
+}
+
+func TestECSHandlerEnabled(t *testing.T) {
+	h := NewECSHandler(&bytes.Buffer{}, "orchestrated-ping", "1.0.0", slog.LevelWarn)
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info enabled at warn level")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("error disabled at warn level")
+	}
+}
+
+func TestECSHandlerConcurrentWrites(t *testing.T) {
+	const goroutines, perGoroutine = 50, 100
+
+	var buf bytes.Buffer
+	log := slog.New(NewECSHandler(&buf, "orchestrated-ping", "1.0.0", slog.LevelInfo))
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Derived loggers share the handler's lock
+			l := log.With(slog.Int("goroutine", g))
+			for i := range perGoroutine {
+				l.Info("concurrent", slog.Int("i", i), slog.String("padding", strings.Repeat("x", 256)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	records := decodeLines(t, &buf)
+	if len(records) != goroutines*perGoroutine {
+		t.Errorf("got %d records, want %d", len(records), goroutines*perGoroutine)
+	}
+}
+
+func TestNewAttachesEnvironment(t *testing.T) {
+	cfg := &config.Config{Environment: "staging"}
+	cfg.Service.Name = "orchestrated-ping"
+	cfg.Service.Version = "1.0.0"
+	cfg.Log.Format = config.LogFormatJSON
+	cfg.Log.Level = "info"
+
+	var buf bytes.Buffer
+	newLogger(cfg, &buf).Info("application starting")
+
+	records := decodeLines(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	if records[0]["service.environment"] != "staging" {
+		t.Errorf("service.environment = %v, want staging", records[0]["service.environment"])
+	}
+}
+
+func TestECSHandlerMapsAccessLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewECSHandler(&buf, "orchestrated-ping", "1.0.0", slog.LevelInfo))
+
+	log.Info("request completed",
+		slog.String("path", "/ping"),
+		slog.String("remote_addr", "192.0.2.1:51234"),
+		slog.String("scheme", "https"),
+		slog.String("host", "ping.example.com"),
+		slog.String("protocol", "2.0"),
+		slog.String("user_agent", "curl/8.5.0"),
+		slog.String("referer", "https://example.com/"),
+		slog.Int("bytes", 42),
+		slog.String("content_type", "application/json"),
+		slog.String("content_encoding", "gzip"),
+		slog.Duration("duration", 1500*time.Microsecond),
+		slog.String("error", "boom"),
+	)
+
+	record := decodeLines(t, &buf)[0]
+	for key, want := range map[string]any{
+		"url.path":                       "/ping",
+		"client.address":                 "192.0.2.1:51234",
+		"url.scheme":                     "https",
+		"url.domain":                     "ping.example.com",
+		"http.version":                   "2.0",
+		"user_agent.original":            "curl/8.5.0",
+		"http.request.referrer":          "https://example.com/",
+		"http.response.body.bytes":       float64(42),
+		"http.response.mime_type":        "application/json",
+		"event.duration":                 float64(1500000),
+		"error.message":                  "boom",
+		"http.response.content_encoding": "gzip",
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+
+	for _, key := range []string{"user_agent", "referer", "protocol", "scheme", "host", "content_encoding"} {
+		if _, ok := record[key]; ok {
+			t.Errorf("%s left at the root", key)
+		}
+	}
+}
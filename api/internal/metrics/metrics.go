@@ -1,27 +1,122 @@
 package metrics
 
 import (
+    "runtime"
+    "runtime/debug"
+
     "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
     "github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 var (
+    // Registry holds every metric exposed on /metrics
+    Registry = prometheus.NewRegistry()
+
+    // Request duration buckets with sub-millisecond resolution, since
+    // prometheus.DefBuckets starts at 5ms and /ping answers in microseconds
+    DefaultDurationBuckets = []float64{
+        0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01,
+        0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+    }
+
     // HTTP request duration in seconds
-    HttpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
-        Name:    "http_request_duration_seconds",
-        Help:    "Duration of HTTP requests in seconds",
-        Buckets: prometheus.DefBuckets,
-    }, []string{"method", "endpoint", "status"})
+    HttpDuration = newHttpDuration(DefaultDurationBuckets)
 
     // HTTP requests total
-    HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+    HttpRequestsTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
         Name: "http_requests_total",
         Help: "Total number of HTTP requests",
     }, []string{"method", "endpoint", "status"})
 
+    // HTTP requests currently being served
+    HttpRequestsInFlight = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+        Name: "http_requests_in_flight",
+        Help: "Number of HTTP requests currently being served",
+    })
+
+    // Panics recovered from HTTP handlers
+    HttpPanicsTotal = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+        Name: "http_panics_total",
+        Help: "Total number of panics recovered from HTTP handlers",
+    })
+
+    // Requests rejected by the rate limiter
+    HttpRateLimitedTotal = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+        Name: "http_rate_limited_total",
+        Help: "Total number of HTTP requests rejected by the rate limiter",
+    })
+
     // Application uptime
-    AppUptime = promauto.NewGauge(prometheus.GaugeOpts{
+    AppUptime = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
         Name: "app_uptime_seconds",
         Help: "Application uptime in seconds",
     })
+
+    // Overall readiness outcome of the last evaluation
+    ServiceReady = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+        Name: "service_ready",
+        Help: "Overall readiness of the service (1 ready, 0.5 degraded, 0 not ready)",
+    })
+
+    // Build metadata, always 1, for joining onto other series
+    BuildInfo = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+        Name: "build_info",
+        Help: "Build information about the running binary, always 1",
+    }, []string{"version", "commit", "go_version"})
+)
+
+func newHttpDuration(buckets []float64) *prometheus.HistogramVec {
+    return promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "http_request_duration_seconds",
+        Help:    "Duration of HTTP requests in seconds",
+        Buckets: buckets,
+    }, []string{"method", "endpoint", "status"})
+}
+
+// SetDurationBuckets replaces the request duration histogram with one using
+// the given buckets. It must be called before any request is served.
+func SetDurationBuckets(buckets []float64) {
+    Registry.Unregister(HttpDuration)
+    HttpDuration = newHttpDuration(buckets)
+}
+
+// commit is set at build time, e.g.
+// -ldflags "-X github.com/arifjehoh/orchestrated-ping/internal/metrics.commit=$GIT_SHA"
+var commit string
+
+// SetBuildInfo publishes the build_info series for the given service version.
+// The commit is the one set at build time, falling back to the VCS revision
+// stamped into the binary, or "unknown" when neither is available.
+func SetBuildInfo(version string) {
+    BuildInfo.WithLabelValues(version, buildCommit(), runtime.Version()).Set(1)
+}
+
+func buildCommit() string {
+    if commit != "" {
+        return commit
+    }
+    if info, ok := debug.ReadBuildInfo(); ok {
+        for _, setting := range info.Settings {
+            if setting.Key == "vcs.revision" {
+                return setting.Value
+            }
+        }
+    }
+    return "unknown"
+}
+
+func init() {
+    // Go runtime and process metrics, e.g. go_goroutines and process_cpu_seconds_total
+    Registry.MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+    )
+}
+
+// Values reported by the ServiceReady gauge
+const (
+    ReadyStateNotReady = 0
+    ReadyStateDegraded = 0.5
+    ReadyStateReady    = 1
 )
\ No newline at end of file
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestSetBuildInfo(t *testing.T) {
+	original := commit
+	t.Cleanup(func() {
+		commit = original
+		BuildInfo.Reset()
+	})
+
+	for _, tt := range []struct {
+		name   string
+		commit string
+	}{
+		{"ldflags commit", "0123abc"},
+		{"fallback", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			BuildInfo.Reset()
+			commit = tt.commit
+			SetBuildInfo("1.2.3")
+
+			families, err := Registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+
+			var found bool
+			for _, family := range families {
+				if family.GetName() != "build_info" {
+					continue
+				}
+				if len(family.GetMetric()) != 1 {
+					t.Fatalf("got %d build_info series, want 1", len(family.GetMetric()))
+				}
+				m := family.GetMetric()[0]
+				labels := make(map[string]string)
+				for _, label := range m.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+
+				wantCommit := tt.commit
+				if wantCommit == "" {
+					wantCommit = buildCommit()
+				}
+				if labels["version"] != "1.2.3" || labels["commit"] != wantCommit || labels["go_version"] != runtime.Version() {
+					t.Errorf("labels = %v", labels)
+				}
+				if labels["commit"] == "" {
+					t.Error("commit label is empty")
+				}
+				if v := m.GetGauge().GetValue(); v != 1 {
+					t.Errorf("value = %v, want 1", v)
+				}
+				found = true
+			}
+			if !found {
+				t.Fatal("build_info not gathered")
+			}
+		})
+	}
+}
+
+// histogramBuckets returns the bucket upper bounds of the request duration
+// histogram and the cumulative counts of the series for endpoint.
+func histogramBuckets(t *testing.T, endpoint string) ([]float64, []uint64) {
+	t.Helper()
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := false
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "endpoint" && label.GetValue() == endpoint {
+					matches = true
+				}
+			}
+			if !matches {
+				continue
+			}
+			var bounds []float64
+			var counts []uint64
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				bounds = append(bounds, bucket.GetUpperBound())
+				counts = append(counts, bucket.GetCumulativeCount())
+			}
+			return bounds, counts
+		}
+	}
+	t.Fatalf("http_request_duration_seconds{endpoint=%q} not gathered", endpoint)
+	return nil, nil
+}
+
+// checkSubMillisecond asserts that a single 0.2ms observation landed in the
+// first bucket that covers it and in no smaller one.
+func checkSubMillisecond(t *testing.T, bounds []float64, counts []uint64) {
+	t.Helper()
+
+	for i, bound := range bounds {
+		want := uint64(0)
+		if bound >= 0.0002 {
+			want = 1
+		}
+		if counts[i] != want {
+			t.Errorf("bucket le=%v has cumulative count %d, want %d", bound, counts[i], want)
+		}
+	}
+}
+
+func TestDurationBuckets(t *testing.T) {
+	t.Cleanup(func() { SetDurationBuckets(DefaultDurationBuckets) })
+
+	SetDurationBuckets(DefaultDurationBuckets)
+	HttpDuration.WithLabelValues("GET", "/sub-ms", "200").Observe(0.0002)
+	bounds, counts := histogramBuckets(t, "/sub-ms")
+	if !slices.Equal(bounds, DefaultDurationBuckets) {
+		t.Errorf("default buckets = %v, want %v", bounds, DefaultDurationBuckets)
+	}
+	if DefaultDurationBuckets[0] >= 0.005 {
+		t.Errorf("default buckets start at %v, want sub-millisecond resolution", DefaultDurationBuckets[0])
+	}
+	checkSubMillisecond(t, bounds, counts)
+
+	custom := []float64{0.0001, 0.001, 0.01, 0.1}
+	SetDurationBuckets(custom)
+	HttpDuration.WithLabelValues("GET", "/sub-ms", "200").Observe(0.0002)
+	bounds, counts = histogramBuckets(t, "/sub-ms")
+	if !slices.Equal(bounds, custom) {
+		t.Errorf("custom buckets = %v, want %v", bounds, custom)
+	}
+	checkSubMillisecond(t, bounds, counts)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Push sends the custom application metrics to a Prometheus Pushgateway
+// under the given job label, for runs too short-lived to be scraped.
+func Push(ctx context.Context, url, job string) error {
+	return push.New(url, job).
+		Collector(HttpDuration).
+		Collector(HttpRequestsTotal).
+		Collector(HttpRequestsInFlight).
+		Collector(HttpPanicsTotal).
+		Collector(HttpRateLimitedTotal).
+		Collector(AppUptime).
+		Collector(ServiceReady).
+		Collector(BuildInfo).
+		PushContext(ctx)
+}
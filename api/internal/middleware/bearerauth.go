@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// BearerAuth requires requests to carry "Authorization: Bearer <token>" and
+// rejects others with a JSON 401. The scheme is case-insensitive, and the
+// token is compared in constant time so response timing does not reveal how
+// much of a guess was correct.
+func BearerAuth(token string) func(next http.Handler) http.Handler {
+	expected := []byte(token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := bearerToken(r.Header.Get("Authorization"))
+			if !ok || subtle.ConstantTimeCompare([]byte(given), expected) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				writeError(w, r, http.StatusUnauthorized, "a valid bearer token is required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken extracts the token from an Authorization header value.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return token, true
+}
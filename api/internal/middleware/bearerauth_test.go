@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerAuth(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := BearerAuth("secret")(next)
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"authorized", "Bearer secret", http.StatusOK},
+		{"lowercase scheme", "bearer secret", http.StatusOK},
+		{"uppercase scheme", "BEARER secret", http.StatusOK},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+		{"other scheme", "Basic c2VjcmV0", http.StatusUnauthorized},
+		{"scheme only", "Bearer", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate not set")
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// MaxBytes limits request bodies to limit bytes. Requests declaring a larger
+// Content-Length are rejected with 413 up front; otherwise the body is wrapped
+// in http.MaxBytesReader so reads beyond the limit fail.
+func MaxBytes(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+func TestMaxBytes(t *testing.T) {
+	var readErr error
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	})
+	h := MaxBytes(8)(next)
+
+	t.Run("within limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ping", strings.NewReader("12345678")))
+		if rec.Code != http.StatusOK || readErr != nil {
+			t.Errorf("status = %d, read error = %v", rec.Code, readErr)
+		}
+	})
+
+	t.Run("declared too large", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ping", strings.NewReader("123456789")))
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("Content-Type = %q, want JSON", ct)
+		}
+
+		var body models.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding body: %v", err)
+		}
+		if body.Status != "error" || body.Error != http.StatusText(http.StatusRequestEntityTooLarge) || body.Message != "request body too large" {
+			t.Errorf("body = %+v", body)
+		}
+	})
+
+	t.Run("undeclared too large", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/ping", strings.NewReader("123456789"))
+		req.ContentLength = -1
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		var maxBytesErr *http.MaxBytesError
+		if !errors.As(readErr, &maxBytesErr) {
+			t.Errorf("read error = %v, want *http.MaxBytesError", readErr)
+		}
+	})
+}
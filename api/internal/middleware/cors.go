@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+)
+
+const (
+	corsAllowedMethods = "GET, OPTIONS"
+	corsAllowedHeaders = "Accept, Authorization, Content-Type"
+	corsMaxAge         = "600"
+)
+
+// CORS sets cross-origin headers for requests from the allowed origins and
+// answers preflight requests. An origin of "*" allows any origin; config
+// rejects combining it with credentials, as browsers do.
+func CORS(origins []string, allowCredentials bool) func(next http.Handler) http.Handler {
+	wildcard := slices.Contains(origins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !wildcard && !slices.Contains(origins, origin) {
+				if preflight {
+					writeError(w, r, http.StatusForbidden, "origin is not allowed")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if wildcard {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if allowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name            string
+		origins         []string
+		credentials     bool
+		origin          string
+		preflight       bool
+		wantStatus      int
+		wantAllowOrigin string
+		wantCredentials string
+	}{
+		{name: "no origin", origins: []string{"https://app.example.com"}, wantStatus: http.StatusOK},
+		{name: "allowed origin", origins: []string{"https://app.example.com"}, origin: "https://app.example.com", wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example.com"},
+		{name: "credentials", origins: []string{"https://app.example.com"}, credentials: true, origin: "https://app.example.com", wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example.com", wantCredentials: "true"},
+		{name: "wildcard", origins: []string{"*"}, origin: "https://any.example.com", wantStatus: http.StatusOK, wantAllowOrigin: "*"},
+		{name: "other origin", origins: []string{"https://app.example.com"}, origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "preflight", origins: []string{"https://app.example.com"}, origin: "https://app.example.com", preflight: true, wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example.com"},
+		{name: "preflight from other origin", origins: []string{"https://app.example.com"}, origin: "https://evil.example.com", preflight: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.preflight {
+				req.Method = http.MethodOptions
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			CORS(tt.origins, tt.credentials)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+			if tt.origin != "" && rec.Header().Get("Vary") == "" {
+				t.Error("Vary not set for a cross-origin request")
+			}
+			if tt.preflight && tt.wantStatus == http.StatusNoContent {
+				if rec.Header().Get("Access-Control-Allow-Methods") != corsAllowedMethods || rec.Header().Get("Access-Control-Max-Age") != corsMaxAge {
+					t.Errorf("preflight headers = %v", rec.Header())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// IPFilter rejects clients by address with a 403. It must run after RealIP so
+// that clients behind a trusted proxy are checked by their own address. A
+// client matching the deny list is always rejected, even if it also matches
+// the allow list. When an allow list is given, clients matching neither list
+// are rejected; otherwise they are permitted. The CIDRs are expected to have
+// been validated by config.
+func IPFilter(allow, deny []string) func(next http.Handler) http.Handler {
+	allowed := mustParsePrefixes(allow)
+	denied := mustParsePrefixes(deny)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr, ok := clientAddr(r.RemoteAddr)
+
+			switch {
+			case ok && containsAddr(denied, addr):
+				writeError(w, r, http.StatusForbidden, "client address is denied")
+				return
+			case len(allowed) > 0 && !(ok && containsAddr(allowed, addr)):
+				writeError(w, r, http.StatusForbidden, "client address is not allowed")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientAddr parses a remote address that is either "ip:port" or, after
+// RealIP has rewritten it from a trusted proxy's header, a bare IP.
+func clientAddr(remoteAddr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(remoteAddr); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParsePrefixes(cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}
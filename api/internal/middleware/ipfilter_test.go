@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		remoteAddr string
+		want       int
+	}{
+		{"no lists", nil, nil, "198.51.100.7:4000", http.StatusOK},
+		{"allowed", []string{"10.0.0.0/8"}, nil, "10.1.2.3:4000", http.StatusOK},
+		{"not allowed", []string{"10.0.0.0/8"}, nil, "198.51.100.7:4000", http.StatusForbidden},
+		{"denied", nil, []string{"198.51.100.0/24"}, "198.51.100.7:4000", http.StatusForbidden},
+		{"deny wins over allow", []string{"10.0.0.0/8"}, []string{"10.1.0.0/16"}, "10.1.2.3:4000", http.StatusForbidden},
+		{"bare IP from RealIP", []string{"10.0.0.0/8"}, nil, "10.1.2.3", http.StatusOK},
+		{"IPv4-mapped IPv6", []string{"10.0.0.0/8"}, nil, "[::ffff:10.1.2.3]:4000", http.StatusOK},
+		{"IPv6", nil, []string{"2001:db8::/32"}, "[2001:db8::1]:4000", http.StatusForbidden},
+		{"unparseable with allow list", []string{"10.0.0.0/8"}, nil, "pipe", http.StatusForbidden},
+		{"unparseable with deny list only", nil, []string{"10.0.0.0/8"}, "pipe", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			IPFilter(tt.allow, tt.deny)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPFilterBehindTrustedProxy(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RealIP([]string{"10.0.0.0/8"})(IPFilter(nil, []string{"203.0.113.0/24"})(next))
+
+	for forwarded, want := range map[string]int{
+		"203.0.113.9":  http.StatusForbidden,
+		"198.51.100.7": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("forwarded for %s: status = %d, want %d", forwarded, rec.Code, want)
+		}
+	}
+}
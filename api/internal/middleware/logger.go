@@ -3,25 +3,47 @@ package middleware
 import (
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func Logger(logger *slog.Logger) func(next http.Handler) http.Handler {
+// Logger writes an access log record per request. Successful requests are
+// sampled at sampleRate, while failed requests and those slower than
+// slowThreshold are always logged.
+func Logger(logger *slog.Logger, sampleRate float64, slowThreshold time.Duration) func(next http.Handler) http.Handler {
+	sampler := &sampler{rate: sampleRate}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww := wrapResponseWriter(w)
 
 			defer func() {
-				logger.Info("request completed",
+				duration := time.Since(start)
+				success := ww.statusCode >= 200 && ww.statusCode < 300
+				slow := slowThreshold > 0 && duration > slowThreshold
+				if success && !slow && !sampler.sample() {
+					return
+				}
+
+				logger.InfoContext(r.Context(), "request completed",
 					slog.String("method", r.Method),
 					slog.String("path", r.URL.Path),
 					slog.String("remote_addr", r.RemoteAddr),
-					slog.Int("status", ww.Status()),
-					slog.Int("bytes", ww.BytesWritten()),
-					slog.Duration("duration", time.Since(start)),
+					slog.String("scheme", requestScheme(r)),
+					slog.String("host", requestHost(r)),
+					slog.String("protocol", strconv.Itoa(r.ProtoMajor)+"."+strconv.Itoa(r.ProtoMinor)),
+					slog.String("user_agent", r.UserAgent()),
+					slog.String("referer", r.Referer()),
+					slog.Int("status", ww.statusCode),
+					slog.Int("bytes", ww.bytesWritten),
+					slog.Duration("duration", duration),
+					slog.String("content_type", ww.Header().Get("Content-Type")),
+					slog.String("content_encoding", ww.Header().Get("Content-Encoding")),
 					slog.String("request_id", middleware.GetReqID(r.Context())),
 				)
 			}()
@@ -30,3 +52,34 @@ func Logger(logger *slog.Logger) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// sampler selects a fixed fraction of calls. Spacing the selected calls
+// evenly by counting, rather than drawing at random, keeps the logged share
+// exact over any window.
+type sampler struct {
+	rate  float64
+	count atomic.Uint64
+}
+
+func (s *sampler) sample() bool {
+	if s.rate >= 1 {
+		return true
+	}
+
+	n := s.count.Add(1)
+	return uint64(float64(n)*s.rate) > uint64(float64(n-1)*s.rate)
+}
+
+// requestScheme reports the scheme the request arrived over.
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the requested host name without its port.
+func requestHost(r *http.Request) string {
+	u := url.URL{Host: r.Host}
+	return u.Hostname()
+}
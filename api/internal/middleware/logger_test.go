@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+	"github.com/arifjehoh/orchestrated-ping/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// accessLogRouter serves h at /ping behind the request ID, tracing and logging
+// middleware in production order, writing ECS records to buf.
+func accessLogRouter(buf *bytes.Buffer, sampleRate float64, h http.HandlerFunc) *chi.Mux {
+	log := slog.New(logger.NewECSHandler(buf, "test", "0.0.0", slog.LevelInfo))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(RequestIDHeader())
+	r.Use(Tracing())
+	r.Use(Logger(log, sampleRate, 0))
+	r.Get("/ping", h)
+	return r
+}
+
+// accessLogRecords parses the ECS records written to buf.
+func accessLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("decoding log: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggerRecordsRequestID(t *testing.T) {
+	// As installed by tracing.Setup, so that every request has a span
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, 1, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	records := accessLogRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	id := rec.Header().Get(middleware.RequestIDHeader)
+	if id == "" || records[0]["http.request.id"] != id {
+		t.Errorf("http.request.id = %v, want the X-Request-Id %q", records[0]["http.request.id"], id)
+	}
+	if traceID, _ := records[0]["trace.id"].(string); len(traceID) != 32 || traceID == id {
+		t.Errorf("trace.id = %v, want the OpenTelemetry trace ID", records[0]["trace.id"])
+	}
+}
+
+func TestLoggerEmitsHTTPFields(t *testing.T) {
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, 1, func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "http://ping.example.com:8080/ping", nil)
+	req.Header.Set("User-Agent", "kube-probe/1.30")
+	req.Header.Set("Referer", "https://example.com/")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := accessLogRecords(t, &buf)[0]
+	for key, want := range map[string]any{
+		"http.request.method":   "GET",
+		"url.path":              "/ping",
+		"url.scheme":            "http",
+		"url.domain":            "ping.example.com",
+		"http.version":          "1.1",
+		"user_agent.original":   "kube-probe/1.30",
+		"http.request.referrer": "https://example.com/",
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+}
+
+func TestLoggerRecordsContentTypeAndEncoding(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	h := handlers.New(cfg, discardLogger, time.Now(), nil)
+
+	// The request headers drive both content negotiation and compression
+	ping := middleware.Compress(5, "text/plain")(http.HandlerFunc(h.Ping))
+
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, 1, ping.ServeHTTP)
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := accessLogRecords(t, &buf)[0]
+	if record["http.response.mime_type"] != "text/plain; charset=utf-8" {
+		t.Errorf("http.response.mime_type = %v, want text/plain; charset=utf-8", record["http.response.mime_type"])
+	}
+	if record["http.response.content_encoding"] != "gzip" {
+		t.Errorf("http.response.content_encoding = %v, want gzip", record["http.response.content_encoding"])
+	}
+}
+
+func TestLoggerRecordsStatusAndSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus float64
+		wantBytes  float64
+	}{
+		{
+			name:       "implicit 200",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("pong")) },
+			wantStatus: 200,
+			wantBytes:  4,
+		},
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created"))
+				w.Write([]byte("!"))
+			},
+			wantStatus: 201,
+			wantBytes:  8,
+		},
+		{
+			name:       "no body",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			wantStatus: 204,
+			wantBytes:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			accessLogRouter(&buf, 1, tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+			record := accessLogRecords(t, &buf)[0]
+			if record["http.response.status_code"] != tt.wantStatus || record["http.response.body.bytes"] != tt.wantBytes {
+				t.Errorf("status = %v, bytes = %v, want %v and %v",
+					record["http.response.status_code"], record["http.response.body.bytes"], tt.wantStatus, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	var buf bytes.Buffer
+	status := http.StatusOK
+	r := accessLogRouter(&buf, 0.25, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	for range 20 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	}
+	if got := len(accessLogRecords(t, &buf)); got != 5 {
+		t.Errorf("logged %d of 20 successful requests, want 5", got)
+	}
+
+	// Failed requests are always logged
+	status = http.StatusInternalServerError
+	for range 4 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	}
+	if got := len(accessLogRecords(t, &buf)); got != 4 {
+		t.Errorf("logged %d of 4 failed requests, want 4", got)
+	}
+}
+
+func TestLoggerAlwaysLogsSlowRequests(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(logger.NewECSHandler(&buf, "test", "0.0.0", slog.LevelInfo))
+
+	r := chi.NewRouter()
+	r.Use(Logger(log, 0, time.Millisecond))
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) { time.Sleep(5 * time.Millisecond) })
+	r.Get("/fast", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	records := accessLogRecords(t, &buf)
+	if len(records) != 1 || records[0]["url.path"] != "/ping" {
+		t.Errorf("records = %v, want only the slow request", records)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	for _, rate := range []float64{0, 0.1, 0.5, 1} {
+		s := &sampler{rate: rate}
+		var sampled int
+		for range 1000 {
+			if s.sample() {
+				sampled++
+			}
+		}
+		if want := int(rate * 1000); sampled != want {
+			t.Errorf("rate %g: sampled %d of 1000, want %d", rate, sampled, want)
+		}
+	}
+}
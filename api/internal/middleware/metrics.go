@@ -12,8 +12,12 @@ import (
 func Metrics() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Deferred so the gauge is restored even if the handler panics
+			metrics.HttpRequestsInFlight.Inc()
+			defer metrics.HttpRequestsInFlight.Dec()
+
 			start := time.Now()
-			ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			ww := wrapResponseWriter(w)
 
 			next.ServeHTTP(ww, r)
 
@@ -26,13 +30,3 @@ func Metrics() func(next http.Handler) http.Handler {
 		})
 	}
 }
-
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsInFlight(t *testing.T) {
+	var during float64
+	r := chi.NewRouter()
+	r.Use(Recoverer(discardLogger))
+	r.Use(Metrics())
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		during = testutil.ToFloat64(metrics.HttpRequestsInFlight)
+	})
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	before := testutil.ToFloat64(metrics.HttpRequestsInFlight)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if during != before+1 {
+		t.Errorf("in flight during the request = %v, want %v", during, before+1)
+	}
+	if got := testutil.ToFloat64(metrics.HttpRequestsInFlight); got != before {
+		t.Errorf("in flight after the request = %v, want %v", got, before)
+	}
+
+	// The gauge is restored when the handler panics
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if got := testutil.ToFloat64(metrics.HttpRequestsInFlight); got != before {
+		t.Errorf("in flight after a panic = %v, want %v", got, before)
+	}
+}
+
+func TestMetricsRecordsRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics())
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	counter := metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, "/items/{id}", "202")
+	before := testutil.ToFloat64(counter)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/42", nil))
+	if got := testutil.ToFloat64(counter); got != before+1 {
+		t.Errorf("http_requests_total = %v, want %v", got, before+1)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+)
+
+// RateLimit applies a token bucket per client address, refilled at rps
+// tokens per second up to burst. Requests over the limit get a JSON 429 with
+// a Retry-After header and are counted in http_rate_limited_total. It must
+// run after RealIP so that clients behind a trusted proxy are told apart.
+func RateLimit(rps float64, burst int) func(next http.Handler) http.Handler {
+	limiter := newRateLimiter(rps, burst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.RemoteAddr
+			if addr, ok := clientAddr(r.RemoteAddr); ok {
+				key = addr.String()
+			}
+
+			if retryAfter, ok := limiter.allow(key, time.Now()); !ok {
+				metrics.HttpRateLimitedTotal.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitSweepInterval is how often idle client buckets are dropped.
+const rateLimitSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	rps       float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rps:     rps,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the client's bucket. When the bucket is empty it
+// returns how long until a token becomes available.
+func (l *rateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rps * float64(time.Second)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
+
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// for the same client would be identical.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRateLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RateLimit(1, 2)(next)
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	before := testutil.ToFloat64(metrics.HttpRateLimitedTotal)
+
+	for i := range 2 {
+		if rec := request("192.0.2.1:1000"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: status = %d", i, rec.Code)
+		}
+	}
+
+	// The port differs per connection, the client is the same
+	rec := request("192.0.2.1:2000")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+	}
+	if got := testutil.ToFloat64(metrics.HttpRateLimitedTotal); got != before+1 {
+		t.Errorf("http_rate_limited_total = %v, want %v", got, before+1)
+	}
+
+	// Other clients have their own bucket
+	if rec := request("192.0.2.2:1000"); rec.Code != http.StatusOK {
+		t.Errorf("other client: status = %d", rec.Code)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l := newRateLimiter(10, 1)
+	now := time.Now()
+
+	if _, ok := l.allow("a", now); !ok {
+		t.Fatal("first request rejected")
+	}
+	retryAfter, ok := l.allow("a", now)
+	if ok || retryAfter != 100*time.Millisecond {
+		t.Errorf("allow() = %s, %v, want 100ms, false", retryAfter, ok)
+	}
+	if _, ok := l.allow("a", now.Add(100*time.Millisecond)); !ok {
+		t.Error("request after refill rejected")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := newRateLimiter(10, 1)
+	now := time.Now()
+
+	l.allow("a", now)
+	l.allow("b", now.Add(rateLimitSweepInterval+time.Second))
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("idle bucket not swept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("active bucket swept")
+	}
+}
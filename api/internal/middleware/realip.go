@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces r.RemoteAddr with the client address reported by a proxy,
+// but only when the connecting peer is one of the trusted proxies. Requests
+// from anywhere else keep their socket address, so clients cannot spoof
+// their way past IPFilter or RateLimit with forwarding headers. The CIDRs are
+// expected to have been validated by config.
+func RealIP(trustedProxies []string) func(next http.Handler) http.Handler {
+	proxies := mustParsePrefixes(trustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := forwardedClient(r, proxies); ok {
+				r.RemoteAddr = addr.String()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient resolves the client address from True-Client-IP, X-Real-IP
+// or X-Forwarded-For when the request came through a trusted proxy.
+func forwardedClient(r *http.Request, proxies []netip.Prefix) (netip.Addr, bool) {
+	peer, ok := clientAddr(r.RemoteAddr)
+	if !ok || !containsAddr(proxies, peer) {
+		return netip.Addr{}, false
+	}
+
+	for _, header := range []string{"True-Client-IP", "X-Real-IP"} {
+		if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(header))); err == nil {
+			return addr.Unmap(), true
+		}
+	}
+
+	// Each proxy appends the address it received the request from, so the
+	// client is the rightmost hop that is not itself a trusted proxy
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		if addr = addr.Unmap(); !containsAddr(proxies, addr) {
+			return addr, true
+		}
+	}
+
+	return netip.Addr{}, false
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "untrusted peer keeps socket address",
+			remoteAddr: "203.0.113.5:1234",
+			headers:    map[string]string{"X-Real-IP": "10.1.2.3"},
+			want:       "203.0.113.5:1234",
+		},
+		{
+			name:       "trusted proxy with X-Real-IP",
+			remoteAddr: "192.168.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "True-Client-IP takes precedence",
+			remoteAddr: "192.168.0.1:1234",
+			headers:    map[string]string{"True-Client-IP": "198.51.100.7", "X-Real-IP": "203.0.113.5"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "X-Forwarded-For skips trusted hops",
+			remoteAddr: "192.168.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.9.9.9, 203.0.113.5, 192.168.0.2"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "malformed X-Forwarded-For is ignored",
+			remoteAddr: "192.168.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "not-an-ip"},
+			want:       "192.168.0.1:1234",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "192.168.0.1:1234",
+			want:       "192.168.0.1:1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP([]string{"192.168.0.0/16"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPFilterCannotBeBypassedWithHeaders(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RealIP([]string{"192.168.0.0/16"})(IPFilter([]string{"10.0.0.0/8"}, nil)(next))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		want       int
+	}{
+		{"untrusted client", "203.0.113.5:1234", "", http.StatusForbidden},
+		{"untrusted client spoofing header", "203.0.113.5:1234", "10.1.2.3", http.StatusForbidden},
+		{"allowed client via trusted proxy", "192.168.0.1:1234", "10.1.2.3", http.StatusOK},
+		{"denied client via trusted proxy", "192.168.0.1:1234", "203.0.113.5", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
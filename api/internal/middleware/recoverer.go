@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer recovers from handler panics, logging the panic value and stack
+// trace as a structured record, counting it in http_panics_total and replying
+// with a JSON 500. http.ErrAbortHandler is re-panicked so the server can
+// abort the response as intended.
+func Recoverer(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				// Panics from handlers run on another goroutine, as by
+				// Timeout, carry the stack of the goroutine that panicked
+				stack := debug.Stack()
+				if p, ok := rvr.(*handlerPanic); ok {
+					rvr, stack = p.value, p.stack
+				}
+
+				metrics.HttpPanicsTotal.Inc()
+
+				logger.ErrorContext(r.Context(), "panic recovered",
+					slog.String("error", fmt.Sprint(rvr)),
+					slog.String("stack", string(stack)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				writeError(w, r, http.StatusInternalServerError, "internal server error")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handlerPanic carries a panic recovered on a handler goroutine, together
+// with that goroutine's stack, to be re-panicked on the serving goroutine.
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *handlerPanic) String() string {
+	return fmt.Sprint(p.value)
+}
+
+// withStack wraps a recovered panic value with the current stack, leaving
+// http.ErrAbortHandler as is so the server still recognises it.
+func withStack(p any) any {
+	if p == http.ErrAbortHandler {
+		return p
+	}
+	if _, ok := p.(*handlerPanic); ok {
+		return p
+	}
+	return &handlerPanic{value: p, stack: debug.Stack()}
+}
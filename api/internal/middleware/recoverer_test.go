@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h := middleware.RequestID(Recoverer(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	before := testutil.ToFloat64(metrics.HttpPanicsTotal)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	var body models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Message != "internal server error" {
+		t.Errorf("body = %q (%v)", rec.Body.String(), err)
+	}
+	if got := testutil.ToFloat64(metrics.HttpPanicsTotal); got != before+1 {
+		t.Errorf("http_panics_total = %v, want %v", got, before+1)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log: %v (%q)", err, buf.String())
+	}
+	if record["msg"] != "panic recovered" || record["error"] != "boom" || record["path"] != "/ping" {
+		t.Errorf("log record = %v", record)
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "TestRecoverer") {
+		t.Errorf("stack does not reach the panicking handler: %q", stack)
+	}
+	if record["request_id"] == "" {
+		t.Error("request_id not logged")
+	}
+}
+
+func TestRecovererRepanicsAbortHandler(t *testing.T) {
+	h := Recoverer(discardLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+}
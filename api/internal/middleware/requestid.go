@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader echoes the request ID assigned by chi's RequestID middleware
+// in the response, so clients can correlate a response with its log lines.
+// The ID is logged as http.request.id, as trace.id holds the OpenTelemetry
+// trace ID whenever a span is active. It must run after RequestID.
+func RequestIDHeader() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := middleware.GetReqID(r.Context()); id != "" {
+				w.Header().Set(middleware.RequestIDHeader, id)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
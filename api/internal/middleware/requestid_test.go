@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	var assigned string
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(RequestIDHeader())
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		assigned = middleware.GetReqID(r.Context())
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	if assigned == "" {
+		t.Fatal("no request ID assigned")
+	}
+	if got := rec.Header().Get(middleware.RequestIDHeader); got != assigned {
+		t.Errorf("%s = %q, want %q", middleware.RequestIDHeader, got, assigned)
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+)
+
+// defaultErrorContentType is used for middleware errors when
+// ErrorContentType is not in the chain.
+const defaultErrorContentType = "application/json"
+
+type errorContentTypeKey struct{}
+
+// ErrorContentType sets the content type of the JSON errors written by the
+// middleware that runs after it, so that they match the handlers' responses,
+// e.g. "application/json; charset=utf-8".
+func ErrorContentType(contentType string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), errorContentTypeKey{}, contentType)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// errorContentType returns the content type set by ErrorContentType.
+func errorContentType(ctx context.Context) string {
+	if contentType, ok := ctx.Value(errorContentTypeKey{}).(string); ok {
+		return contentType
+	}
+	return defaultErrorContentType
+}
+
+// writeError writes a JSON error response for requests rejected by middleware
+// before they reach a handler.
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	writeErrorAs(w, errorContentType(r.Context()), statusCode, message)
+}
+
+func writeErrorAs(w http.ResponseWriter, contentType string, statusCode int, message string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Status:  "error",
+		Error:   http.StatusText(statusCode),
+		Message: message,
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestErrorContentType(t *testing.T) {
+	const charsetJSON = "application/json; charset=utf-8"
+
+	release := make(chan struct{})
+	defer close(release)
+
+	newRouter := func(mw ...func(http.Handler) http.Handler) *chi.Mux {
+		r := chi.NewRouter()
+		r.Use(mw...)
+		r.With(BearerAuth("secret")).Get("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+		r.With(Timeout(NewTimeoutRegistry(10*time.Millisecond, nil), discardLogger)).Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+		return r
+	}
+
+	for _, tt := range []struct {
+		name   string
+		router *chi.Mux
+		path   string
+		want   int
+		wantCT string
+	}{
+		{"middleware error", newRouter(ErrorContentType(charsetJSON)), "/metrics", http.StatusUnauthorized, charsetJSON},
+		{"timeout", newRouter(ErrorContentType(charsetJSON)), "/slow", http.StatusServiceUnavailable, charsetJSON},
+		{"default", newRouter(), "/metrics", http.StatusUnauthorized, "application/json"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.wantCT {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.wantCT)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// TimeoutRegistry holds per-route request deadlines keyed by chi route
+// pattern, falling back to a default for unlisted routes. A zero duration
+// disables the deadline. Patterns are those of the innermost router, so
+// they do not include the prefix a router is mounted under.
+type TimeoutRegistry struct {
+	defaultTimeout time.Duration
+	routes         map[string]time.Duration
+}
+
+func NewTimeoutRegistry(defaultTimeout time.Duration, routes map[string]time.Duration) *TimeoutRegistry {
+	return &TimeoutRegistry{
+		defaultTimeout: defaultTimeout,
+		routes:         routes,
+	}
+}
+
+// Lookup returns the deadline for a route pattern.
+func (t *TimeoutRegistry) Lookup(pattern string) time.Duration {
+	if timeout, ok := t.routes[pattern]; ok {
+		return timeout
+	}
+	return t.defaultTimeout
+}
+
+// Enabled reports whether any route has a deadline.
+func (t *TimeoutRegistry) Enabled() bool {
+	if t.defaultTimeout > 0 {
+		return true
+	}
+	for _, timeout := range t.routes {
+		if timeout > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Timeout enforces a hard deadline on request handling, taken from the
+// registry for the route the request will be dispatched to. Unlike chi's
+// Timeout, which only cancels the request context, a handler that ignores
+// cancellation is cut off at the deadline: the client receives a JSON 503
+// if nothing was written yet, and a response already in progress is
+// aborted. Writes pass straight through, so handlers can stream and flush
+// before the deadline. Timed out requests are logged with their route
+// pattern.
+//
+// This replaces http.TimeoutHandler, which cannot serve this purpose: its
+// buffering writer does not implement http.Flusher, so streaming handlers
+// only worked with the timeout disabled, and it re-panics handler panics on
+// the serving goroutine, so Recoverer logged a stack without the panicking
+// frame. Like http.TimeoutHandler, the handler runs on its own goroutine and
+// may outlive the request, which is why it gets a private writer and routing
+// context.
+func Timeout(timeouts *TimeoutRegistry, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routed, pattern := findRoute(r)
+			timeout := timeouts.Lookup(ownRoutePattern(routed, pattern))
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			// The router reuses its routing context once this middleware
+			// returns, so the handler routes on a copy it may keep using
+			// after being abandoned at the deadline
+			rctx := chi.RouteContext(ctx)
+			var detached *chi.Context
+			r, detached = detachRouteContext(r, rctx)
+
+			tw := &timeoutWriter{w: w, header: make(http.Header), contentType: errorContentType(r.Context())}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			// The handler runs in its own goroutine so it can be abandoned at
+			// the deadline
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- withStack(p)
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.finish()
+				copyRouting(rctx, detached)
+			case <-ctx.Done():
+				// Outer middleware still reads the matched route
+				copyRouting(rctx, routed)
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					// The client went away, there is no one to respond to
+					tw.abandon()
+					return
+				}
+
+				logger.WarnContext(r.Context(), "request timed out",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("route", pattern),
+					slog.Duration("duration", timeout),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				if !tw.timeOut() {
+					// Part of the response is out, so it cannot be replaced;
+					// abort it rather than let it look complete
+					panic(http.ErrAbortHandler)
+				}
+			}
+		})
+	}
+}
+
+// findRoute resolves the route a request will match. Router middleware runs
+// before routing, so the route is looked up ahead of time. Routes is always
+// the top-level router, even inside a mounted one, so the full path is
+// matched. It returns the routing context as matching left it, nil outside
+// a router, and the full pattern, including any mount prefix.
+func findRoute(r *http.Request) (*chi.Context, string) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil, ""
+	}
+
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	found := chi.NewRouteContext()
+	return found, rctx.Routes.Find(found, r.Method, path)
+}
+
+// ownRoutePattern returns the pattern matched within the innermost router.
+func ownRoutePattern(routed *chi.Context, pattern string) string {
+	if pattern == "" || len(routed.RoutePatterns) == 0 {
+		return pattern
+	}
+	return routed.RoutePatterns[len(routed.RoutePatterns)-1]
+}
+
+// detachRouteContext returns r with its own copy of the routing context.
+func detachRouteContext(r *http.Request, rctx *chi.Context) (*http.Request, *chi.Context) {
+	if rctx == nil {
+		return r, nil
+	}
+
+	detached := chi.NewRouteContext()
+	detached.Routes = rctx.Routes
+	detached.RoutePath = rctx.RoutePath
+	detached.RouteMethod = rctx.RouteMethod
+	copyRouting(detached, rctx)
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, detached)), detached
+}
+
+// copyRouting copies the matched route patterns and URL parameters, which
+// middleware such as Metrics read after the handler returns.
+func copyRouting(dst, src *chi.Context) {
+	if dst == nil || src == nil {
+		return
+	}
+	dst.RoutePatterns = append(dst.RoutePatterns[:0], src.RoutePatterns...)
+	dst.URLParams.Keys = append(dst.URLParams.Keys[:0], src.URLParams.Keys...)
+	dst.URLParams.Values = append(dst.URLParams.Values[:0], src.URLParams.Values...)
+}
+
+// timeoutWriter passes a handler's response through to w until the deadline,
+// after which further writes fail with http.ErrHandlerTimeout. The handler
+// gets its own header map so that it never races with the timeout response.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	// contentType is used for the 503 sent at the deadline
+	contentType string
+
+	mu          sync.Mutex
+	wroteHeader bool
+	// err is returned to writes made after the deadline
+	err error
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.err == nil {
+		tw.writeHeaderLocked(code)
+	}
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+// Flush sends buffered data to the client, for streaming handlers.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.err != nil {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		tw.writeHeaderLocked(http.StatusOK)
+		f.Flush()
+	}
+}
+
+// finish completes a response whose handler returned in time.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeaderLocked(http.StatusOK)
+}
+
+// timeOut stops the handler's writes and sends the JSON 503, reporting false
+// when the handler had already started its response.
+func (tw *timeoutWriter) timeOut() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.err = http.ErrHandlerTimeout
+	if tw.wroteHeader {
+		return false
+	}
+
+	tw.wroteHeader = true
+	writeErrorAs(tw.w, tw.contentType, http.StatusServiceUnavailable, "request timed out")
+	return true
+}
+
+// abandon stops the handler's writes without responding.
+func (tw *timeoutWriter) abandon() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.err = context.Canceled
+}
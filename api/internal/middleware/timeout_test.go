@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func timeoutRouter(timeouts *TimeoutRegistry, logger *slog.Logger, pattern string, h http.HandlerFunc) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(Recoverer(logger))
+	r.Use(Timeout(timeouts, logger))
+	r.Get(pattern, h)
+	return r
+}
+
+func TestTimeoutRespondsWithJSONAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// The handler ignores context cancellation
+	r := timeoutRouter(NewTimeoutRegistry(20*time.Millisecond, nil), discardLogger, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("too late"))
+	})
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("response took %s, want about the 20ms deadline", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var body models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if body.Status != "error" || body.Message != "request timed out" {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+func TestTimeoutPassesFastResponsesThrough(t *testing.T) {
+	r := timeoutRouter(NewTimeoutRegistry(time.Second, nil), discardLogger, "/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("ok"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "ok" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("got %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestTimeoutAllowsFlushing(t *testing.T) {
+	flushed := make(chan struct{})
+	r := timeoutRouter(NewTimeoutRegistry(time.Second, nil), discardLogger, "/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		close(flushed)
+		w.Write([]byte("second\n"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	<-flushed
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+	if rec.Body.String() != "first\nsecond\n" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestTimeoutDisabledDoesNotCutOffLongRequests(t *testing.T) {
+	timeouts := NewTimeoutRegistry(0, nil)
+	if timeouts.Enabled() {
+		t.Fatal("registry with zero timeouts reports enabled")
+	}
+
+	r := timeoutRouter(timeouts, discardLogger, "/poll", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			w.Write([]byte("done"))
+		case <-r.Context().Done():
+			t.Error("request context was cancelled")
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/poll", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "done" {
+		t.Errorf("got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeoutPerRoute(t *testing.T) {
+	timeouts := NewTimeoutRegistry(time.Second, map[string]time.Duration{
+		"/items/{id}": 10 * time.Millisecond,
+		"/poll":       0,
+	})
+
+	if got := timeouts.Lookup("/items/{id}"); got != 10*time.Millisecond {
+		t.Errorf("Lookup(/items/{id}) = %s", got)
+	}
+	if got := timeouts.Lookup("/other"); got != time.Second {
+		t.Errorf("Lookup(/other) = %s, want default", got)
+	}
+
+	r := chi.NewRouter()
+	r.Use(Timeout(timeouts, discardLogger))
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	}
+	r.Get("/items/{id}", slow)
+	r.Get("/poll", slow)
+
+	for path, want := range map[string]int{"/items/42": http.StatusServiceUnavailable, "/poll": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestTimeoutPerRouteWhenMounted(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	timeouts := NewTimeoutRegistry(time.Second, map[string]time.Duration{
+		"/items/{id}": 10 * time.Millisecond,
+	})
+
+	sub := chi.NewRouter()
+	sub.Use(Timeout(timeouts, logger))
+	sub.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	parent := chi.NewRouter()
+	parent.Mount("/internal", sub)
+
+	rec := httptest.NewRecorder()
+	parent.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/items/7", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	// The log keeps the full pattern
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log: %v (%q)", err, buf.String())
+	}
+	if record["route"] != "/internal/items/{id}" {
+		t.Errorf("route = %v, want /internal/items/{id}", record["route"])
+	}
+}
+
+func TestTimeoutLogsRoutePattern(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := timeoutRouter(NewTimeoutRegistry(10*time.Millisecond, nil), logger, "/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/7", nil))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log: %v (%q)", err, buf.String())
+	}
+	if record["msg"] != "request timed out" || record["route"] != "/items/{id}" {
+		t.Errorf("log record = %v", record)
+	}
+}
+
+func boom() {
+	panic("boom")
+}
+
+func TestTimeoutPanicKeepsHandlerStack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := timeoutRouter(NewTimeoutRegistry(time.Second, nil), logger, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		boom()
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log: %v (%q)", err, buf.String())
+	}
+	if record["error"] != "boom" {
+		t.Errorf("error = %v, want boom", record["error"])
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "middleware.boom") {
+		t.Errorf("stack does not contain the panicking frame:\n%s", stack)
+	}
+}
+
+func TestTimeoutKeepsRoutePatternForOuterMiddleware(t *testing.T) {
+	timeouts := NewTimeoutRegistry(time.Second, map[string]time.Duration{
+		"/slow/{id}": 10 * time.Millisecond,
+	})
+
+	patterns := make(chan string, 1)
+	sub := chi.NewRouter()
+	sub.Use(Timeout(timeouts, discardLogger))
+	sub.Get("/slow/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		// Still routing on its own context after being abandoned
+		_ = chi.URLParam(r, "id")
+	})
+	sub.Get("/fast/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	parent := chi.NewRouter()
+	parent.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			patterns <- chi.RouteContext(r.Context()).RoutePattern()
+		})
+	})
+	parent.Mount("/internal", sub)
+
+	// Later requests reuse the pooled routing context of the abandoned one
+	for _, tt := range []struct{ path, want string }{
+		{"/internal/slow/1", "/internal/slow/{id}"},
+		{"/internal/fast/2", "/internal/fast/{id}"},
+		{"/internal/slow/3", "/internal/slow/{id}"},
+		{"/internal/fast/4", "/internal/fast/{id}"},
+	} {
+		parent.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := <-patterns; got != tt.want {
+			t.Errorf("%s: outer middleware saw pattern %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	// Let the abandoned handlers finish before the test ends
+	time.Sleep(50 * time.Millisecond)
+}
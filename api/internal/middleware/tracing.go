@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/arifjehoh/orchestrated-ping/internal/middleware"
+
+// Tracing starts a server span per request, continuing the trace from an
+// incoming W3C traceparent header when present. The span is stored in the
+// request context so that log records carry its trace and span IDs, which
+// means it must run before Logger.
+func Tracing() func(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			ww := wrapResponseWriter(w)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// The route is only known once the router has matched it
+			if pattern := chi.RouteContext(ctx).RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(attribute.String("http.route", pattern))
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", ww.statusCode))
+			if ww.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(ww.statusCode))
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	var logs bytes.Buffer
+	log := slog.New(logger.NewECSHandler(&logs, "test", "0.0.0", slog.LevelInfo))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(RequestIDHeader())
+	r.Use(Tracing())
+	r.Use(Logger(log, 1, 0))
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log line %q: %v", logs.String(), err)
+	}
+	if record["trace.id"] != traceID {
+		t.Errorf("trace.id = %v, want %s", record["trace.id"], traceID)
+	}
+	if record["span.id"] == nil {
+		t.Error("span.id not logged")
+	}
+	if id := rec.Header().Get(middleware.RequestIDHeader); record["http.request.id"] != id {
+		t.Errorf("http.request.id = %v, want the echoed request ID %q", record["http.request.id"], id)
+	}
+}
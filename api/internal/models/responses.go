@@ -6,11 +6,54 @@ type Response struct {
 	Status  string    `json:"status"`
 	Message string    `json:"message"`
 	Time    time.Time `json:"time"`
+	Meta    *Meta     `json:"_meta,omitempty"`
 }
 
-type HealthResponse struct {
+// PlainText renders the response as its message on a single line, e.g.
+// "pong" for /ping.
+func (r Response) PlainText() string {
+	return r.Message + "\n"
+}
+
+type ReadinessResponse struct {
+	Status   string         `json:"status"`
+	Message  string         `json:"message"`
+	Time     time.Time      `json:"time"`
+	Failures []CheckFailure `json:"failures,omitempty"`
+	Meta     *Meta          `json:"_meta,omitempty"`
+}
+
+// CheckFailure describes a readiness check that did not pass.
+type CheckFailure struct {
+	Name string `json:"name"`
+	// Status is "failed", or "skipped" when a prerequisite did not pass
 	Status string `json:"status"`
-	Uptime string `json:"uptime"`
+	Error  string `json:"error"`
+}
+
+// Values of CheckFailure.Status
+const (
+	CheckStatusFailed  = "failed"
+	CheckStatusSkipped = "skipped"
+)
+
+type HealthResponse struct {
+	Status        string  `json:"status"`
+	Uptime        string  `json:"uptime"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Meta          *Meta   `json:"_meta,omitempty"`
+}
+
+type ClockResponse struct {
+	Status     string    `json:"status"`
+	ServerTime time.Time `json:"server_time"`
+	ClientTime string    `json:"client_time,omitempty"`
+	Meta       *Meta     `json:"_meta,omitempty"`
+}
+
+// Meta carries out-of-band information such as deprecation warnings.
+type Meta struct {
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestErrorResponses(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	routes := srv.httpServer.Handler
+
+	parent := chi.NewRouter()
+	parent.Mount("/internal", routes)
+
+	for _, tt := range []struct {
+		name    string
+		router  http.Handler
+		method  string
+		path    string
+		want    int
+		wantHdr string
+		wantMsg string
+	}{
+		{"unknown route", routes, http.MethodGet, "/does-not-exist", http.StatusNotFound, "", "no route matches /does-not-exist"},
+		{"get route", routes, http.MethodPost, "/ping", http.StatusMethodNotAllowed, "GET", "method POST is not allowed on /ping"},
+		{"mounted", parent, http.MethodPost, "/internal/ready", http.StatusMethodNotAllowed, "GET", "method POST is not allowed on /internal/ready"},
+		{"allowed", routes, http.MethodGet, "/ping", http.StatusOK, "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantHdr {
+				t.Errorf("Allow = %q, want %q", got, tt.wantHdr)
+			}
+			if tt.wantMsg == "" {
+				return
+			}
+
+			var body models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v (%q)", err, rec.Body.String())
+			}
+			want := models.ErrorResponse{Status: "error", Error: http.StatusText(tt.want), Message: tt.wantMsg}
+			if body != want {
+				t.Errorf("body = %+v, want %+v", body, want)
+			}
+		})
+	}
+}
+
+func TestRoutesMountedUnderPrefix(t *testing.T) {
+	srv, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.API.Deprecations = map[string]string{"/ping": "use /v2/ping"}
+	})
+
+	parent := chi.NewRouter()
+	parent.Mount("/internal", srv.httpServer.Handler)
+
+	for _, tt := range []struct {
+		path       string
+		want       int
+		deprecated bool
+	}{
+		{"/internal/ping", http.StatusOK, true},
+		{"/internal/health", http.StatusOK, false},
+		{"/ping", http.StatusNotFound, false},
+	} {
+		rec := httptest.NewRecorder()
+		parent.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+		if got := rec.Header().Get("X-API-Deprecation") != ""; got != tt.deprecated {
+			t.Errorf("%s: deprecated = %v, want %v", tt.path, got, tt.deprecated)
+		}
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"disabled", "", "", http.StatusOK},
+		{"authorized", "secret", "Bearer secret", http.StatusOK},
+		{"unauthorized", "secret", "", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t, func(cfg *config.Config) {
+				cfg.Metrics.Token = tt.token
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsExposesRuntimeCollectors(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	want := []string{"go_goroutines ", "go_memstats_alloc_bytes ", "app_uptime_seconds "}
+	// The process collector only reports on platforms with procfs
+	if runtime.GOOS == "linux" {
+		want = append(want, "process_cpu_seconds_total ", "process_open_fds ")
+	}
+	body := rec.Body.String()
+	for _, name := range want {
+		if !strings.Contains(body, "\n"+name) {
+			t.Errorf("/metrics is missing %s", strings.TrimSpace(name))
+		}
+	}
+}
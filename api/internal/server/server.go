@@ -3,11 +3,12 @@ package server
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
-	"time"
 
 	"github.com/arifjehoh/orchestrated-ping/internal/config"
 	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
 	"github.com/arifjehoh/orchestrated-ping/internal/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,50 +16,133 @@ import (
 )
 
 type Server struct {
-	httpServer *http.Server
-	logger     *slog.Logger
+	httpServer  *http.Server
+	logger      *slog.Logger
+	listener    net.Listener
+	tlsCertFile string
+	tlsKeyFile  string
 }
 
 func New(cfg *config.Config, logger *slog.Logger, handler *handlers.Handler) *Server {
-	router := setupRouter(logger, handler)
+	router := Routes(cfg, logger, handler)
 
 	srv := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
+		Addr:              cfg.Server.Addr(),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
-	return &Server{
+	s := &Server{
 		httpServer: srv,
 		logger:     logger,
 	}
+
+	if cfg.Server.TLSEnabled() {
+		s.tlsCertFile = cfg.Server.TLSCertFile
+		s.tlsKeyFile = cfg.Server.TLSKeyFile
+	}
+
+	return s
 }
 
-func setupRouter(logger *slog.Logger, handler *handlers.Handler) *chi.Mux {
+// Routes returns the service endpoints behind the full middleware stack. It
+// backs the standalone server and can be mounted into a larger chi router,
+// e.g. parent.Mount("/internal", server.Routes(cfg, logger, handler)).
+func Routes(cfg *config.Config, logger *slog.Logger, handler *handlers.Handler) http.Handler {
 	r := chi.NewRouter()
 
+	r.Use(middleware.ErrorContentType(cfg.API.JSONContentType()))
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
-	r.Use(middleware.Logger(logger))
+	r.Use(middleware.RequestIDHeader())
+	if len(cfg.Access.TrustedProxies) > 0 {
+		r.Use(middleware.RealIP(cfg.Access.TrustedProxies))
+	}
+	r.Use(middleware.Tracing())
+	r.Use(middleware.Logger(logger, cfg.Log.SampleRate, cfg.Log.SlowThreshold))
 	r.Use(middleware.Metrics())
-	r.Use(chimiddleware.Recoverer)
-	r.Use(chimiddleware.Timeout(60 * time.Second))
+	if len(cfg.Access.AllowCIDRs) > 0 || len(cfg.Access.DenyCIDRs) > 0 {
+		r.Use(middleware.IPFilter(cfg.Access.AllowCIDRs, cfg.Access.DenyCIDRs))
+	}
+	if cfg.Access.RateLimitRPS > 0 {
+		r.Use(middleware.RateLimit(cfg.Access.RateLimitRPS, cfg.Access.RateLimitBurst))
+	}
+	if len(cfg.Access.CORSAllowedOrigins) > 0 {
+		r.Use(middleware.CORS(cfg.Access.CORSAllowedOrigins, cfg.Access.CORSAllowCredentials))
+	}
+	r.Use(middleware.MaxBytes(cfg.Server.MaxRequestBytes))
+	r.Use(middleware.Recoverer(logger))
+	// A zero request timeout disables the deadline, e.g. for long-polling
+	timeouts := middleware.NewTimeoutRegistry(cfg.Server.RequestTimeout, cfg.Server.RouteTimeouts)
+	if timeouts.Enabled() {
+		r.Use(middleware.Timeout(timeouts, logger))
+	}
+
+	r.NotFound(handler.NotFound)
+	r.MethodNotAllowed(handler.MethodNotAllowed)
 
 	r.Get("/ping", handler.Ping)
+	r.Get("/ping/clock", handler.Clock)
 	r.Get("/health", handler.Health)
+	r.Get("/livez", handler.Live)
 	r.Get("/ready", handler.Ready)
-	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/startupz", handler.Startup)
+
+	metricsHandler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+	if cfg.Metrics.Token != "" {
+		r.With(middleware.BearerAuth(cfg.Metrics.Token)).Handle("/metrics", metricsHandler)
+	} else {
+		r.Handle("/metrics", metricsHandler)
+	}
 
 	return r
 }
 
+// Listen binds the server address without serving yet, so that a caller can
+// tell the port is open before reporting the process as started.
+func (s *Server) Listen() error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = ln
+	return nil
+}
+
+// Addr returns the bound address, or the configured one before Listen.
+func (s *Server) Addr() string {
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.httpServer.Addr
+}
+
+// Start serves requests until Shutdown, binding the address first unless
+// Listen has already done so.
 func (s *Server) Start() error {
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	tlsEnabled := s.tlsCertFile != ""
+
 	s.logger.Info("starting server",
-		slog.String("address", s.httpServer.Addr),
+		slog.String("address", s.Addr()),
+		slog.Bool("tls", tlsEnabled),
 	)
 
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	var err error
+	if tlsEnabled {
+		// HTTP/2 is negotiated automatically over TLS
+		err = s.httpServer.ServeTLS(s.listener, s.tlsCertFile, s.tlsKeyFile)
+	} else {
+		err = s.httpServer.Serve(s.listener)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
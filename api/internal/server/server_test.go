@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestServer builds a server on a free loopback port. mutate adjusts the
+// configuration before the server is created.
+func newTestServer(t *testing.T, mutate func(*config.Config), checks ...handlers.ReadinessCheck) (*Server, *handlers.Handler) {
+	t.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	cfg.Server.BindAddress = "127.0.0.1"
+	cfg.Server.Port = 0
+	if mutate != nil {
+		mutate(cfg)
+	}
+
+	handler := handlers.New(cfg, discardLogger, time.Now(), checks)
+	return New(cfg, discardLogger, handler), handler
+}
+
+// serve starts srv in the background and shuts it down when the test ends.
+func serve(t *testing.T, srv *Server) {
+	t.Helper()
+
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- srv.Start() }()
+	t.Cleanup(func() {
+		srv.httpServer.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	})
+}
+
+func TestListenBindsBeforeStart(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer srv.listener.Close()
+
+	// Connections queue in the backlog before Serve is called
+	conn, err := net.DialTimeout("tcp", srv.Addr(), time.Second)
+	if err != nil {
+		t.Fatalf("dial before Start: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenPortInUse(t *testing.T) {
+	first, _ := newTestServer(t, nil)
+	if err := first.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer first.listener.Close()
+
+	second, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = first.listener.Addr().(*net.TCPAddr).Port
+	})
+	if err := second.Listen(); err == nil {
+		second.listener.Close()
+		t.Fatal("Listen() on a bound port succeeded")
+	}
+}
+
+func TestStartupProbeFlipsAfterMarkStarted(t *testing.T) {
+	srv, handler := newTestServer(t, nil)
+	serve(t, srv)
+
+	url := "http://" + srv.Addr() + "/startupz"
+	if code := getStatus(t, url); code != http.StatusServiceUnavailable {
+		t.Errorf("before MarkStarted: status %d, want 503", code)
+	}
+
+	handler.MarkStarted()
+	if code := getStatus(t, url); code != http.StatusOK {
+		t.Errorf("after MarkStarted: status %d, want 200", code)
+	}
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
+
+func TestServerTimeouts(t *testing.T) {
+	t.Setenv("READ_TIMEOUT", "7s")
+	t.Setenv("WRITE_TIMEOUT", "20s")
+	t.Setenv("READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("IDLE_TIMEOUT", "90s")
+
+	srv, _ := newTestServer(t, nil)
+
+	got := [4]time.Duration{
+		srv.httpServer.ReadTimeout,
+		srv.httpServer.WriteTimeout,
+		srv.httpServer.ReadHeaderTimeout,
+		srv.httpServer.IdleTimeout,
+	}
+	want := [4]time.Duration{7 * time.Second, 20 * time.Second, 2 * time.Second, 90 * time.Second}
+	if got != want {
+		t.Errorf("read, write, read header and idle timeouts = %v, want %v", got, want)
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.Probe.CheckTimeout = 5 * time.Second
+	}, handlers.ReadinessCheck{Name: "slow", Check: func(ctx context.Context) error {
+		close(entered)
+		<-release
+		return nil
+	}})
+	serve(t, srv)
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + srv.Addr() + "/ready")
+		if err != nil {
+			t.Errorf("in-flight request failed: %v", err)
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(ctx) }()
+
+	// Shutdown waits for the request rather than cutting it off
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if code := <-responses; code != http.StatusOK {
+		t.Errorf("in-flight request: status %d, want 200", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Setup installs the global tracer provider and W3C trace context propagator.
+// Spans are always created so that trace and span IDs reach the logs, but
+// they are only exported when an OTLP endpoint is configured. The returned
+// function flushes and stops the provider.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.Service.Name),
+			attribute.String("service.version", cfg.Service.Version),
+			attribute.String("deployment.environment", cfg.Environment),
+		)),
+	}
+
+	if cfg.Tracing.OTLPEndpoint != "" {
+		// The exporter reads the OTEL_EXPORTER_OTLP_* variables itself, which
+		// appends /v1/traces to a base endpoint and lets
+		// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT take precedence
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
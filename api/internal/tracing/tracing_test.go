@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupExportsToSpecPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		traces   string
+		wantPath string
+	}{
+		{name: "base endpoint", base: "/", wantPath: "/v1/traces"},
+		{name: "base endpoint with path", base: "/otel/", wantPath: "/otel/v1/traces"},
+		{name: "traces endpoint takes precedence", base: "/", traces: "/custom/spans", wantPath: "/custom/spans"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := make(chan string, 1)
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case paths <- r.URL.Path:
+				default:
+				}
+			}))
+			defer collector.Close()
+
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL+tt.base)
+			if tt.traces != "" {
+				t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", collector.URL+tt.traces)
+			}
+			cfg, err := config.Load()
+			if err != nil {
+				t.Fatalf("config.Load() error = %v", err)
+			}
+
+			shutdown, err := Setup(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Setup() error = %v", err)
+			}
+			_, span := otel.Tracer("test").Start(context.Background(), "ping")
+			span.End()
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatalf("shutdown error = %v", err)
+			}
+
+			select {
+			case got := <-paths:
+				if got != tt.wantPath {
+					t.Errorf("spans sent to %q, want %q", got, tt.wantPath)
+				}
+			default:
+				t.Fatal("no spans exported")
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,9 +12,18 @@ import (
 
 	"github.com/arifjehoh/orchestrated-ping/internal/config"
 	"github.com/arifjehoh/orchestrated-ping/internal/handlers"
+	"github.com/arifjehoh/orchestrated-ping/internal/jitter"
 	"github.com/arifjehoh/orchestrated-ping/internal/logger"
 	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
 	"github.com/arifjehoh/orchestrated-ping/internal/server"
+	"github.com/arifjehoh/orchestrated-ping/internal/tracing"
+)
+
+const (
+	// finalPushTimeout bounds the Pushgateway push made on the way out
+	finalPushTimeout = 5 * time.Second
+	// finalFlushTimeout bounds the export of buffered spans on the way out
+	finalFlushTimeout = 5 * time.Second
 )
 
 func main() {
@@ -27,32 +38,60 @@ func main() {
 	log := logger.New(cfg)
 	slog.SetDefault(log)
 
+	// Initialize tracing, spans are only exported when an endpoint is set
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Error("failed to initialize tracing", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if len(cfg.Metrics.DurationBuckets) > 0 {
+		metrics.SetDurationBuckets(cfg.Metrics.DurationBuckets)
+	}
+	metrics.SetBuildInfo(cfg.Service.Version)
+
 	// Record start time for uptime tracking
 	startTime := time.Now()
 
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			uptime := time.Since(startTime).Seconds()
-			// Update the uptime metric
-			metrics.AppUptime.Set(uptime)
-		}
-	}()
+	// Spread periodic tasks so replicas do not run them in lockstep
+	seed := uint64(time.Now().UnixNano())
+	taskJitter := jitter.New(cfg.Tasks.Jitter, rand.New(rand.NewPCG(seed, seed)))
+
+	// Background tasks stop once shutdown begins
+	tasksCtx, stopTasks := context.WithCancel(context.Background())
+	defer stopTasks()
 
-	// Initialize handlers with dependencies
-	handler := handlers.New(log, startTime)
+	updateUptime := uptimeUpdater(startTime)
+	updateUptime()
+	go taskJitter.Run(tasksCtx, 10*time.Second, updateUptime)
+
+	// Periodically push metrics when a Pushgateway interval is configured
+	if cfg.Metrics.PushgatewayURL != "" && cfg.Metrics.PushgatewayInterval > 0 {
+		go taskJitter.Run(tasksCtx, cfg.Metrics.PushgatewayInterval, func() {
+			pushMetrics(tasksCtx, cfg, log)
+		})
+	}
+
+	// Initialize handlers with dependencies. Readiness checks for downstream
+	// dependencies are registered here.
+	var readinessChecks []handlers.ReadinessCheck
+	handler := handlers.New(cfg, log, startTime, readinessChecks)
+
+	// The liveness watchdog runs until the process exits
+	go handler.RunWatchdog(context.Background())
 
 	// Create and start server
 	srv := server.New(cfg, log, handler)
 
-	// Log application startup
-	log.Info("application starting",
-		slog.String("service", cfg.Service.Name),
-		slog.String("version", cfg.Service.Version),
-		slog.String("environment", cfg.Environment),
-		slog.String("port", cfg.Server.Port),
-	)
+	// Log application startup with the resolved configuration
+	log.LogAttrs(context.Background(), slog.LevelInfo, "application starting", cfg.LogAttrs()...)
+
+	// Bind the port up front so the startup probe never passes before the
+	// server can accept connections
+	if err := srv.Listen(); err != nil {
+		log.Error("failed to listen", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
 	// Start server in a goroutine
 	go func() {
@@ -62,22 +101,107 @@ func main() {
 		}
 	}()
 
+	// Evaluate readiness once so the first probe and the service_ready gauge
+	// reflect the dependencies rather than an unknown state
+	if !handler.EvaluateReadiness(context.Background()) {
+		log.Warn("readiness checks failing at startup")
+	}
+
+	// Initialization is complete, let the startup probe pass
+	handler.MarkStarted()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("received shutdown signal")
+	stopTasks()
+
+	// A second signal aborts the drain
+	go func() {
+		<-quit
+		log.Error("received second shutdown signal, aborting drain")
+		os.Exit(cfg.ExitCodes.Signal)
+	}()
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Attempt graceful shutdown
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("server forced to shutdown", slog.String("error", err.Error()))
-		os.Exit(1)
+	// Attempt graceful shutdown, draining in-flight requests
+	drainStart := time.Now()
+	err = srv.Shutdown(ctx)
+	if err != nil {
+		log.Error("server forced to shutdown",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(drainStart)),
+		)
+	}
+
+	// Push final metrics so short-lived runs are not lost
+	updateUptime()
+	pushFinalMetrics(cfg, log, finalPushTimeout)
+
+	// Flush any buffered spans, also when the drain was cut short
+	flushTracing(shutdownTracing, log, finalFlushTimeout)
+
+	if err == nil {
+		log.Info("server stopped gracefully",
+			slog.Duration("duration", time.Since(drainStart)),
+		)
+	}
+	os.Exit(shutdownExitCode(err, cfg.ExitCodes))
+}
+
+// shutdownExitCode maps the outcome of the graceful shutdown to the
+// configured process exit code.
+func shutdownExitCode(err error, codes config.ExitCodesConfig) int {
+	switch {
+	case err == nil:
+		return codes.Clean
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DrainTimeout
+	default:
+		return 1
+	}
+}
+
+// uptimeUpdater returns a task that sets the app_uptime_seconds gauge to the
+// time elapsed since startTime.
+func uptimeUpdater(startTime time.Time) func() {
+	return func() {
+		metrics.AppUptime.Set(time.Since(startTime).Seconds())
+	}
+}
+
+func pushMetrics(ctx context.Context, cfg *config.Config, log *slog.Logger) {
+	if err := metrics.Push(ctx, cfg.Metrics.PushgatewayURL, cfg.Metrics.PushgatewayJob); err != nil {
+		log.Error("failed to push metrics", slog.String("error", err.Error()))
+	}
+}
+
+// pushFinalMetrics pushes metrics one last time before the process exits. It
+// has a deadline of its own, as the drain may have used up the shutdown
+// context.
+func pushFinalMetrics(cfg *config.Config, log *slog.Logger, timeout time.Duration) {
+	if cfg.Metrics.PushgatewayURL == "" {
+		return
 	}
 
-	log.Info("server stopped gracefully")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	pushMetrics(ctx, cfg, log)
+}
+
+// flushTracing exports buffered spans before the process exits. Like
+// pushFinalMetrics it has a deadline of its own, as the drain may have used
+// up the shutdown context.
+func flushTracing(shutdown func(context.Context) error, log *slog.Logger, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		log.Error("failed to shut down tracing", slog.String("error", err.Error()))
+	}
 }
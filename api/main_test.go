@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arifjehoh/orchestrated-ping/internal/config"
+	"github.com/arifjehoh/orchestrated-ping/internal/jitter"
+	"github.com/arifjehoh/orchestrated-ping/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestPushFinalMetrics(t *testing.T) {
+	pushes := make(chan string, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushes <- r.Method + " " + r.URL.Path
+	}))
+	defer gateway.Close()
+
+	cfg := &config.Config{}
+	cfg.Metrics.PushgatewayURL = gateway.URL
+	cfg.Metrics.PushgatewayJob = "ping"
+
+	pushFinalMetrics(cfg, discardLogger, time.Second)
+
+	select {
+	case got := <-pushes:
+		if want := http.MethodPut + " /metrics/job/ping"; got != want {
+			t.Errorf("push = %q, want %q", got, want)
+		}
+	default:
+		t.Fatal("no push received")
+	}
+}
+
+func TestPushFinalMetricsIsBounded(t *testing.T) {
+	release := make(chan struct{})
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer gateway.Close()
+	defer close(release)
+
+	cfg := &config.Config{}
+	cfg.Metrics.PushgatewayURL = gateway.URL
+	cfg.Metrics.PushgatewayJob = "ping"
+
+	start := time.Now()
+	pushFinalMetrics(cfg, discardLogger, 20*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("push took %s, want about the 20ms deadline", elapsed)
+	}
+}
+
+func TestPushFinalMetricsDisabled(t *testing.T) {
+	// Without a URL there is nothing to push to, and nothing should block
+	pushFinalMetrics(&config.Config{}, discardLogger, time.Second)
+}
+
+func TestUptimeUpdater(t *testing.T) {
+	updateUptime := uptimeUpdater(time.Now().Add(-time.Minute))
+
+	updateUptime()
+	first := testutil.ToFloat64(metrics.AppUptime)
+	if first < 60 {
+		t.Fatalf("app_uptime_seconds = %v, want at least 60", first)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	updateUptime()
+	if second := testutil.ToFloat64(metrics.AppUptime); second <= first {
+		t.Errorf("app_uptime_seconds = %v after %v, want it to increase", second, first)
+	}
+}
+
+func TestUptimeUpdaterStopsOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var updates atomic.Int32
+	updateUptime := uptimeUpdater(time.Now())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		jitter.New(0, nil).Run(ctx, time.Millisecond, func() {
+			updateUptime()
+			updates.Add(1)
+		})
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for updates.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("updater still running after cancel")
+	}
+	if updates.Load() < 2 {
+		t.Errorf("updater ran %d times, want at least 2", updates.Load())
+	}
+}
+
+func TestShutdownExitCode(t *testing.T) {
+	codes := config.ExitCodesConfig{Clean: 0, DrainTimeout: 3, Signal: 4}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"drained", nil, 0},
+		{"drain timeout", context.DeadlineExceeded, 3},
+		{"wrapped drain timeout", fmt.Errorf("shutdown: %w", context.DeadlineExceeded), 3},
+		{"other error", errors.New("listener closed twice"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shutdownExitCode(tt.err, codes); got != tt.want {
+				t.Errorf("shutdownExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlushTracingHasItsOwnDeadline(t *testing.T) {
+	flushed := false
+	flushTracing(func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			t.Errorf("flush context already done: %v", ctx.Err())
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("flush context has no deadline")
+		}
+		flushed = true
+		return nil
+	}, discardLogger, time.Second)
+
+	if !flushed {
+		t.Error("spans were not flushed")
+	}
+}